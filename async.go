@@ -0,0 +1,68 @@
+package telegram
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+)
+
+var ErrStopped = errors.New("connection stopped")
+
+type SendResult struct {
+	Result json.RawMessage
+	Err    error
+}
+
+type asyncSend struct {
+	method string
+	data   interface{}
+	result chan SendResult
+}
+
+func (c *Connection) SendAsync(method string, data interface{}) <-chan SendResult {
+	s := asyncSend{method, data, make(chan SendResult, 1)}
+	c.mu.Lock()
+	c.sendQueue = append(c.sendQueue, s)
+	if c.sendSignal == nil {
+		c.sendSignal = make(chan struct{}, 1)
+	}
+	start := !c.sending
+	c.sending = true
+	c.mu.Unlock()
+	if start {
+		c.goroutine(c.processSendQueue)
+	}
+	select {
+	case c.sendSignal <- struct{}{}:
+	default:
+	}
+	return s.result
+}
+
+func (c *Connection) processSendQueue(ctx context.Context) {
+	for {
+		c.mu.Lock()
+		queue, stopped := c.sendQueue, ctx.Err() != nil
+		c.sendQueue = nil
+		if stopped {
+			c.sending = false
+		}
+		c.mu.Unlock()
+		for _, s := range queue {
+			if ctx.Err() != nil {
+				s.result <- SendResult{Err: ErrStopped}
+				continue
+			}
+			result := json.RawMessage{}
+			err := c.Call(s.method, s.data, &result)
+			s.result <- SendResult{result, err}
+		}
+		if stopped {
+			return
+		}
+		select {
+		case <-ctx.Done():
+		case <-c.sendSignal:
+		}
+	}
+}
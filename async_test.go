@@ -0,0 +1,46 @@
+package telegram_test
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/niklasfasching/telegram"
+	"github.com/niklasfasching/telegram/telegramtest"
+)
+
+func TestSendAsync(t *testing.T) {
+	const n = 20
+	s := telegramtest.NewServer()
+	defer s.Close()
+	c := s.Connection()
+	c.Throttle = true
+	defer c.StopAndWait()
+	start, results := time.Now(), []<-chan telegram.SendResult{}
+	for i := 1; i <= n; i++ {
+		results = append(results, c.SendAsync("sendMessage", map[string]interface{}{"chat_id": i, "text": "hi"}))
+	}
+	if elapsed := time.Since(start); elapsed > 100*time.Millisecond {
+		t.Errorf("expected SendAsync to return immediately, took %s", elapsed)
+	}
+	for i, r := range results {
+		select {
+		case res := <-r:
+			m := telegram.Message{}
+			if res.Err != nil {
+				t.Fatal(res.Err)
+			} else if err := json.Unmarshal(res.Result, &m); err != nil || m.Chat.ID != int64(i+1) {
+				t.Errorf("unexpected result %s: %v", res.Result, err)
+			}
+		case <-time.After(5 * time.Second):
+			t.Fatal("timed out waiting for the sends")
+		}
+	}
+	// 30 messages per second overall, so n sends take at least (n-1)/30s.
+	if elapsed, min := time.Since(start), (n-1)*time.Second/30; elapsed < min {
+		t.Errorf("expected the sends to be throttled to at least %s, took %s", min, elapsed)
+	}
+	if got := len(s.SentMessages()); got != n {
+		t.Errorf("expected %d sent messages, got %d", n, got)
+	}
+}
@@ -0,0 +1,48 @@
+package telegram
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+func ValidateLoginWidget(data map[string]string, token string, maxAge time.Duration) (User, error) {
+	secret := sha256.Sum256([]byte(token))
+	mac := hmac.New(sha256.New, secret[:])
+	mac.Write([]byte(dataCheckString(data)))
+	if hash, err := hex.DecodeString(data["hash"]); err != nil || !hmac.Equal(hash, mac.Sum(nil)) {
+		return User{}, fmt.Errorf("invalid login widget hash")
+	}
+	authDate, err := strconv.ParseInt(data["auth_date"], 10, 64)
+	if err != nil {
+		return User{}, fmt.Errorf("invalid login widget auth_date: %w", err)
+	}
+	if age := time.Since(time.Unix(authDate, 0)); maxAge > 0 && age > maxAge {
+		return User{}, fmt.Errorf("login widget data is outdated (%s old)", age.Round(time.Second))
+	}
+	id, err := strconv.ParseInt(data["id"], 10, 64)
+	if err != nil {
+		return User{}, fmt.Errorf("invalid login widget id: %w", err)
+	}
+	return User{ID: id, FirstName: data["first_name"], LastName: data["last_name"], Username: data["username"]}, nil
+}
+
+func dataCheckString(data map[string]string) string {
+	keys := []string{}
+	for k := range data {
+		if k != "hash" {
+			keys = append(keys, k)
+		}
+	}
+	sort.Strings(keys)
+	lines := make([]string, len(keys))
+	for i, k := range keys {
+		lines[i] = k + "=" + data[k]
+	}
+	return strings.Join(lines, "\n")
+}
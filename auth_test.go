@@ -0,0 +1,51 @@
+package telegram_test
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/niklasfasching/telegram"
+	"github.com/niklasfasching/telegram/telegramtest"
+)
+
+// signLoginWidget hashes data as described in
+// https://core.telegram.org/widgets/login#checking-authorization.
+func signLoginWidget(data map[string]string, token string) map[string]string {
+	secret := sha256.Sum256([]byte(token))
+	mac := hmac.New(sha256.New, secret[:])
+	mac.Write([]byte("auth_date=" + data["auth_date"] + "\nfirst_name=" + data["first_name"] + "\nid=" + data["id"] + "\nusername=" + data["username"]))
+	data["hash"] = hex.EncodeToString(mac.Sum(nil))
+	return data
+}
+
+func TestValidateLoginWidget(t *testing.T) {
+	now := strconv.FormatInt(time.Now().Unix(), 10)
+	data := signLoginWidget(map[string]string{"id": "42", "first_name": "Ann", "username": "ann", "auth_date": now}, telegramtest.Token)
+	user, err := telegram.ValidateLoginWidget(data, telegramtest.Token, time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	} else if user != (telegram.User{ID: 42, FirstName: "Ann", Username: "ann"}) {
+		t.Errorf("unexpected user %#v", user)
+	}
+
+	tampered := map[string]string{}
+	for k, v := range data {
+		tampered[k] = v
+	}
+	tampered["id"] = "43"
+	if _, err := telegram.ValidateLoginWidget(tampered, telegramtest.Token, time.Hour); err == nil {
+		t.Error("expected a tampered payload to be rejected")
+	}
+	if _, err := telegram.ValidateLoginWidget(data, "1:"+telegramtest.Token[2:], time.Hour); err == nil {
+		t.Error("expected another token to be rejected")
+	}
+	old := strconv.FormatInt(time.Now().Add(-2*time.Hour).Unix(), 10)
+	outdated := signLoginWidget(map[string]string{"id": "42", "first_name": "Ann", "username": "ann", "auth_date": old}, telegramtest.Token)
+	if _, err := telegram.ValidateLoginWidget(outdated, telegramtest.Token, time.Hour); err == nil {
+		t.Error("expected outdated data to be rejected")
+	}
+}
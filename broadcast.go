@@ -0,0 +1,60 @@
+package telegram
+
+import (
+	"context"
+	"sync"
+)
+
+const broadcastWorkers = 8
+
+type broadcastKey struct{}
+
+// Broadcast calls method with data for each chat in chatIDs, with chat_id set to
+// that chat, throttled to Telegram's limits regardless of Connection.Throttle.
+// progress, if not nil, is called after each call with the number of calls done
+// so far. The returned map holds the error of each chat that failed.
+func (c *Connection) Broadcast(ctx context.Context, chatIDs []int64, method string, data interface{}, progress func(done, total int)) map[int64]error {
+	ctx = context.WithValue(ctx, broadcastKey{}, true)
+	ids, failed, done, mu := make(chan int64), map[int64]error{}, 0, sync.Mutex{}
+	wg := sync.WaitGroup{}
+	for i := 0; i < broadcastWorkers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for id := range ids {
+				err := c.broadcastTo(ctx, id, method, data)
+				mu.Lock()
+				if done++; err != nil {
+					failed[id] = err
+				}
+				if progress != nil {
+					progress(done, len(chatIDs))
+				}
+				mu.Unlock()
+			}
+		}()
+	}
+	for _, id := range chatIDs {
+		ids <- id
+	}
+	close(ids)
+	wg.Wait()
+	return failed
+}
+
+func (c *Connection) broadcastTo(ctx context.Context, chatID int64, method string, data interface{}) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	params, err := toMap(data)
+	if err != nil {
+		return err
+	}
+	params["chat_id"] = chatID
+	return c.CallContext(ctx, method, params, nil)
+}
+
+func isBroadcast(ctx context.Context) bool {
+	broadcast, _ := ctx.Value(broadcastKey{}).(bool)
+	return broadcast
+}
@@ -0,0 +1,74 @@
+package telegram
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// CallbackFunc handles a callback query; payload is its data without the prefix
+// the handler was registered for. The originating message, if any, is q.Message.
+// ctx carries the update's trace id, see TraceID.
+type CallbackFunc func(ctx context.Context, q CallbackQuery, payload string) error
+
+// HandleCallback handles callback queries whose data starts with prefix; the
+// longest matching prefix wins. The query is answered once fn returns to stop the
+// client's loading indicator, unless fn already answered it itself or returned
+// ErrContinue. Queries that match no prefix go to the callback_query handler
+// registered with Handle.
+func (c *Connection) HandleCallback(prefix string, fn CallbackFunc) {
+	c.handlersMu.Lock()
+	defer c.handlersMu.Unlock()
+	if _, ok := c.callbacks[prefix]; ok {
+		panic(fmt.Errorf("handler for callback prefix %q has already been registered", prefix))
+	}
+	if c.callbacks == nil {
+		c.callbacks = map[string]CallbackFunc{}
+	}
+	c.callbacks[prefix] = fn
+}
+
+func (q CallbackQuery) Answer(c *Connection, opts ...Option) error {
+	return c.AnswerCallbackQuery(q, opts...)
+}
+
+func (c *Connection) handleCallback(ctx context.Context, update map[string]json.RawMessage) (string, error) {
+	c.handlersMu.RLock()
+	hasCallbacks := len(c.callbacks) != 0
+	c.handlersMu.RUnlock()
+	if !hasCallbacks || update["callback_query"] == nil {
+		return "", nil
+	}
+	q := CallbackQuery{}
+	if err := unmarshalJSON(update["callback_query"], &q); err != nil {
+		return "", err
+	}
+	prefix, fn, ok := c.callback(q.Data)
+	if !ok {
+		return "", nil
+	}
+	c.debugLog(tracePrefix(ctx, "callback"), []byte(prettyPrintJSON(update)))
+	err := fn(ctx, q, strings.TrimPrefix(q.Data, prefix))
+	if errors.Is(err, ErrContinue) {
+		// The next handler may still want to answer with a text or an alert.
+		return "callback:" + prefix, err
+	}
+	if answerErr := c.AnswerCallbackQuery(q); err == nil {
+		err = answerErr
+	}
+	return "callback:" + prefix, err
+}
+
+func (c *Connection) callback(data string) (string, CallbackFunc, bool) {
+	c.handlersMu.RLock()
+	defer c.handlersMu.RUnlock()
+	prefix, fn := "", CallbackFunc(nil)
+	for p, f := range c.callbacks {
+		if strings.HasPrefix(data, p) && (fn == nil || len(p) > len(prefix)) {
+			prefix, fn = p, f
+		}
+	}
+	return prefix, fn, fn != nil
+}
@@ -0,0 +1,55 @@
+package telegram_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/niklasfasching/telegram"
+	"github.com/niklasfasching/telegram/telegramtest"
+)
+
+func TestInlineCallbackQuery(t *testing.T) {
+	s := telegramtest.NewServer()
+	defer s.Close()
+	c := s.Connection()
+	markup := telegram.InlineKeyboardMarkup{InlineKeyboard: [][]telegram.InlineKeyboardButton{{{Text: "again", CallbackData: "count:2"}}}}
+	c.HandleCallback("count:", func(ctx context.Context, q telegram.CallbackQuery, payload string) error {
+		if q.Message != nil || q.InlineMessageID != "inline-1" {
+			t.Errorf("unexpected callback query %#v", q)
+		}
+		if err := c.EditInlineMessageText(q.InlineMessageID, "count: "+payload); err != nil {
+			return err
+		}
+		return c.EditInlineMessageReplyMarkup(q.InlineMessageID, markup)
+	})
+	h := &telegramtest.Harness{Connection: c}
+	if d := h.Inject(`{"update_id": 1, "callback_query": {"id": "q", "from": {"id": 1}, "inline_message_id": "inline-1", "chat_instance": "c", "data": "count:1"}}`); d.Err != nil || d.Route != "callback:count:" {
+		t.Fatalf("unexpected dispatch %#v", d)
+	}
+	text, replyMarkup := s.Calls("editMessageText"), s.Calls("editMessageReplyMarkup")
+	if len(text) != 1 || text[0].Params["inline_message_id"] != "inline-1" || text[0].Params["text"] != "count: 1" || text[0].Params["chat_id"] != "" {
+		t.Errorf("unexpected text edit %v", text)
+	}
+	if len(replyMarkup) != 1 || replyMarkup[0].Params["inline_message_id"] != "inline-1" || replyMarkup[0].Params["reply_markup"] == "" {
+		t.Errorf("unexpected markup edit %v", replyMarkup)
+	}
+}
+
+func TestCallbackContinue(t *testing.T) {
+	s := telegramtest.NewServer()
+	defer s.Close()
+	c := s.Connection()
+	c.HandleCallback("vote:", func(ctx context.Context, q telegram.CallbackQuery, payload string) error {
+		return telegram.ErrContinue
+	})
+	c.Handle("callback_query", func(ctx context.Context, q telegram.CallbackQuery) error {
+		return c.AnswerCallbackQuery(q, telegram.WithText("thanks"))
+	})
+	h := &telegramtest.Harness{Connection: c}
+	if d := h.Inject(`{"update_id": 1, "callback_query": {"id": "q", "from": {"id": 1}, "data": "vote:a"}}`); d.Err != nil || d.Route != "callback_query" {
+		t.Fatalf("unexpected dispatch %#v", d)
+	}
+	if calls := s.Calls("answerCallbackQuery"); len(calls) != 1 || calls[0].Params["text"] != "thanks" {
+		t.Errorf("expected only the answer of the next handler, got %v", calls)
+	}
+}
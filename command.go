@@ -0,0 +1,161 @@
+package telegram
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+type topic struct {
+	chatID   int64
+	threadID int
+}
+
+// CommandFunc handles a command; ctx carries the update's trace id, see TraceID.
+type CommandFunc func(ctx context.Context, m Message, args []string) error
+
+type CommandMiddleware func(next CommandFunc) CommandFunc
+
+func (c *Connection) HandleCommand(name string, fn CommandFunc, middleware ...CommandMiddleware) {
+	c.handlersMu.Lock()
+	defer c.handlersMu.Unlock()
+	if c.commands == nil {
+		c.commands = map[string]CommandFunc{}
+	}
+	registerCommand(c.commands, name, fn, middleware)
+}
+
+// HandleChannelCommand is like HandleCommand for commands in channel posts,
+// new and edited ones. Channel posts have no sender, so Message.From is empty.
+func (c *Connection) HandleChannelCommand(name string, fn CommandFunc, middleware ...CommandMiddleware) {
+	c.handlersMu.Lock()
+	defer c.handlersMu.Unlock()
+	if c.channelCommands == nil {
+		c.channelCommands = map[string]CommandFunc{}
+	}
+	registerCommand(c.channelCommands, name, fn, middleware)
+}
+
+func registerCommand(commands map[string]CommandFunc, name string, fn CommandFunc, middleware []CommandMiddleware) {
+	name = strings.ToLower(strings.TrimPrefix(name, "/"))
+	if _, ok := commands[name]; ok {
+		panic(fmt.Errorf("handler for command %s has already been registered", name))
+	}
+	for i := len(middleware) - 1; i >= 0; i-- {
+		fn = middleware[i](fn)
+	}
+	commands[name] = fn
+}
+
+func (c *Connection) HandleTopic(chatID int64, threadID int, fn func(context.Context, Message) error) {
+	key := topic{chatID, threadID}
+	c.handlersMu.Lock()
+	defer c.handlersMu.Unlock()
+	if _, ok := c.topics[key]; ok {
+		panic(fmt.Errorf("handler for topic %d in chat %d has already been registered", threadID, chatID))
+	}
+	if c.topics == nil {
+		c.topics = map[topic]func(context.Context, Message) error{}
+	}
+	c.topics[key] = fn
+}
+
+func RequireAdmin(c *Connection) CommandMiddleware {
+	return func(next CommandFunc) CommandFunc {
+		return func(ctx context.Context, m Message, args []string) error {
+			member, err := c.GetChatMember(m.Chat.ID, m.From.ID)
+			if err != nil {
+				return err
+			}
+			if member.Status != "creator" && member.Status != "administrator" {
+				return c.CallContext(ctx, "sendMessage", map[string]interface{}{
+					"chat_id":             m.Chat.ID,
+					"text":                "not allowed",
+					"reply_to_message_id": m.ID,
+				}, nil)
+			}
+			return next(ctx, m, args)
+		}
+	}
+}
+
+func (c *Connection) handleMessage(ctx context.Context, update map[string]json.RawMessage) (string, error) {
+	c.handlersMu.RLock()
+	hasChannelCommands, hasMessageRoutes := len(c.channelCommands) != 0, len(c.commands) != 0 || len(c.topics) != 0
+	c.handlersMu.RUnlock()
+	if post := channelPost(update); hasChannelCommands && post != nil {
+		m := Message{}
+		if err := unmarshalJSON(post, &m); err != nil {
+			return "", err
+		}
+		if name, args, ok := c.parseCommand(m.Text); ok {
+			if fn, ok := c.command(true, name); ok {
+				c.debugLog(tracePrefix(ctx, "channel command"), []byte(prettyPrintJSON(update)))
+				return "channel_command:" + name, fn(ctx, m, args)
+			}
+		}
+		return "", nil
+	}
+	if !hasMessageRoutes || update["message"] == nil {
+		return "", nil
+	}
+	m := Message{}
+	if err := unmarshalJSON(update["message"], &m); err != nil {
+		return "", err
+	}
+	if name, args, ok := c.parseCommand(m.Text); ok {
+		if fn, ok := c.command(false, name); ok {
+			c.debugLog(tracePrefix(ctx, "command"), []byte(prettyPrintJSON(update)))
+			return "command:" + name, fn(ctx, m, args)
+		}
+	}
+	if m.IsTopicMessage {
+		if fn, ok := c.topic(topic{m.Chat.ID, m.MessageThreadID}); ok {
+			c.debugLog(tracePrefix(ctx, "topic"), []byte(prettyPrintJSON(update)))
+			return fmt.Sprintf("topic:%d/%d", m.Chat.ID, m.MessageThreadID), fn(ctx, m)
+		}
+	}
+	return "", nil
+}
+
+// channelPost returns the channel post of update, whether new or edited.
+func channelPost(update map[string]json.RawMessage) json.RawMessage {
+	if update["channel_post"] != nil {
+		return update["channel_post"]
+	}
+	return update["edited_channel_post"]
+}
+
+func (c *Connection) command(channel bool, name string) (CommandFunc, bool) {
+	c.handlersMu.RLock()
+	defer c.handlersMu.RUnlock()
+	if channel {
+		fn, ok := c.channelCommands[name]
+		return fn, ok
+	}
+	fn, ok := c.commands[name]
+	return fn, ok
+}
+
+func (c *Connection) topic(key topic) (func(context.Context, Message) error, bool) {
+	c.handlersMu.RLock()
+	defer c.handlersMu.RUnlock()
+	fn, ok := c.topics[key]
+	return fn, ok
+}
+
+func (c *Connection) parseCommand(text string) (string, []string, bool) {
+	if !strings.HasPrefix(text, "/") {
+		return "", nil, false
+	}
+	fields := strings.Fields(text)
+	name := strings.TrimPrefix(fields[0], "/")
+	if i := strings.Index(name, "@"); i != -1 {
+		if !strings.EqualFold(name[i+1:], c.User().Username) {
+			return "", nil, false
+		}
+		name = name[:i]
+	}
+	return strings.ToLower(name), fields[1:], name != ""
+}
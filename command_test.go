@@ -0,0 +1,102 @@
+package telegram_test
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"testing"
+
+	"github.com/niklasfasching/telegram"
+	"github.com/niklasfasching/telegram/telegramtest"
+)
+
+func TestRequireAdmin(t *testing.T) {
+	s := telegramtest.NewServer()
+	defer s.Close()
+	s.RespondFunc("getChatMember", func(call telegramtest.Call) interface{} {
+		status := map[string]string{"1": "creator", "2": "administrator"}[call.Params["user_id"]]
+		if status == "" {
+			status = "member"
+		}
+		return telegram.ChatMember{Status: status}
+	})
+	c := s.Connection()
+	banned := []int64{}
+	c.HandleCommand("ban", func(ctx context.Context, m telegram.Message, args []string) error {
+		banned = append(banned, m.From.ID)
+		return nil
+	}, telegram.RequireAdmin(c))
+	h := &telegramtest.Harness{Connection: c}
+	for i, userID := range []int64{1, 2, 3} {
+		update := fmt.Sprintf(`{"update_id": %d, "message": {"message_id": %d, "from": {"id": %d}, "chat": {"id": -100, "type": "group"}, "text": "/ban"}}`, i+1, i+1, userID)
+		if d := h.Inject(update); d.Err != nil || d.Route != "command:ban" {
+			t.Fatalf("unexpected dispatch %#v", d)
+		}
+	}
+	if want := []int64{1, 2}; !reflect.DeepEqual(banned, want) {
+		t.Errorf("expected only admins to run the command, got %v", banned)
+	}
+	calls := s.Calls("sendMessage")
+	if len(calls) != 1 || calls[0].Params["text"] != "not allowed" || calls[0].Params["reply_to_message_id"] != "3" {
+		t.Errorf("expected the non-admin to be told off, got %v", calls)
+	}
+}
+
+func TestHandleTopic(t *testing.T) {
+	s := telegramtest.NewServer()
+	defer s.Close()
+	c := s.Connection()
+	topics, messages := []int{}, []int{}
+	c.HandleTopic(-100, 5, func(ctx context.Context, m telegram.Message) error {
+		topics = append(topics, m.MessageThreadID)
+		return nil
+	})
+	c.Handle("message", func(m telegram.Message) error {
+		messages = append(messages, m.MessageThreadID)
+		return nil
+	})
+	h := &telegramtest.Harness{Connection: c}
+	for i, test := range []struct {
+		threadID int
+		route    string
+	}{{5, "topic:-100/5"}, {6, "message"}} {
+		update := fmt.Sprintf(`{"update_id": %d, "message": {"message_id": %d, "message_thread_id": %d, "is_topic_message": true, "chat": {"id": -100, "type": "supergroup", "is_forum": true}, "text": "hi"}}`, i+1, i+1, test.threadID)
+		if d := h.Inject(update); d.Err != nil || d.Route != test.route {
+			t.Errorf("thread %d: got route %q (%v), want %q", test.threadID, d.Route, d.Err, test.route)
+		}
+	}
+	if !reflect.DeepEqual(topics, []int{5}) || !reflect.DeepEqual(messages, []int{6}) {
+		t.Errorf("got topic messages %v and generic messages %v", topics, messages)
+	}
+}
+
+func TestHandleChannelCommand(t *testing.T) {
+	s := telegramtest.NewServer()
+	defer s.Close()
+	c := s.Connection()
+	posts, messages := [][]string{}, 0
+	c.HandleChannelCommand("post", func(ctx context.Context, m telegram.Message, args []string) error {
+		if m.From.ID != 0 || m.Chat.Type != "channel" {
+			t.Errorf("unexpected channel post %#v", m)
+		}
+		posts = append(posts, args)
+		return nil
+	})
+	c.HandleCommand("post", func(ctx context.Context, m telegram.Message, args []string) error {
+		messages++
+		return nil
+	})
+	h := &telegramtest.Harness{Connection: c}
+	if d := h.Inject(`{"update_id": 1, "channel_post": {"message_id": 1, "chat": {"id": -100, "type": "channel"}, "text": "/post hello"}}`); d.Err != nil || d.Route != "channel_command:post" {
+		t.Fatalf("unexpected dispatch %#v", d)
+	}
+	if d := h.Inject(`{"update_id": 2, "message": {"message_id": 2, "from": {"id": 1}, "chat": {"id": 1, "type": "private"}, "text": "/post"}}`); d.Err != nil || d.Route != "command:post" {
+		t.Fatalf("unexpected dispatch %#v", d)
+	}
+	if d := h.Inject(`{"update_id": 3, "edited_channel_post": {"message_id": 1, "chat": {"id": -100, "type": "channel"}, "text": "/post edited"}}`); d.Err != nil || d.Route != "channel_command:post" {
+		t.Fatalf("unexpected dispatch %#v", d)
+	}
+	if !reflect.DeepEqual(posts, [][]string{{"hello"}, {"edited"}}) || messages != 1 {
+		t.Errorf("got channel commands %v and %d message commands", posts, messages)
+	}
+}
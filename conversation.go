@@ -0,0 +1,150 @@
+package telegram
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// StateStore persists conversation states. Set with an empty state deletes the
+// key, a ttl of 0 keeps the state until it is changed. Get returns "" for keys
+// without a state or whose ttl has expired.
+type StateStore interface {
+	Get(key int64) (string, error)
+	Set(key int64, state string, ttl time.Duration) error
+}
+
+type MemoryStateStore struct {
+	mu     sync.Mutex
+	states map[int64]memoryState
+}
+
+type memoryState struct {
+	state   string
+	expires time.Time
+}
+
+// StateFunc handles a message in the state it was registered for and returns
+// the next state; returning "" ends the conversation.
+type StateFunc func(ctx context.Context, m Message) (string, error)
+
+// Conversation routes messages of chats that are in a state to the StateFunc of
+// that state instead of the regular handlers. Register it with
+// Connection.Use(conv.Middleware) and start a conversation with Enter, e.g. from
+// a command handler. Store defaults to a MemoryStateStore; Timeout ends
+// conversations that have been idle for that long. With ByUser states are kept
+// per sender rather than per chat.
+type Conversation struct {
+	Store   StateStore
+	Timeout time.Duration
+	ByUser  bool
+
+	states map[string]StateFunc
+	mu     sync.Mutex
+}
+
+func (s *MemoryStateStore) Get(key int64) (string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	st, ok := s.states[key]
+	if !ok {
+		return "", nil
+	} else if !st.expires.IsZero() && time.Now().After(st.expires) {
+		delete(s.states, key)
+		return "", nil
+	}
+	return st.state, nil
+}
+
+func (s *MemoryStateStore) Set(key int64, state string, ttl time.Duration) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if state == "" {
+		delete(s.states, key)
+		return nil
+	}
+	if s.states == nil {
+		s.states = map[int64]memoryState{}
+	}
+	st := memoryState{state: state}
+	if ttl > 0 {
+		st.expires = time.Now().Add(ttl)
+	}
+	s.states[key] = st
+	return nil
+}
+
+func (cv *Conversation) On(state string, fn StateFunc) {
+	cv.mu.Lock()
+	defer cv.mu.Unlock()
+	if _, ok := cv.states[state]; ok {
+		panic(fmt.Errorf("handler for state %s has already been registered", state))
+	} else if state == "" {
+		panic(fmt.Errorf("state must not be empty"))
+	}
+	if cv.states == nil {
+		cv.states = map[string]StateFunc{}
+	}
+	cv.states[state] = fn
+}
+
+func (cv *Conversation) Enter(m Message, state string) error {
+	if _, ok := cv.state(state); !ok {
+		return fmt.Errorf("no handler for state %s", state)
+	}
+	return cv.store().Set(cv.key(m), state, cv.Timeout)
+}
+
+func (cv *Conversation) Leave(m Message) error {
+	return cv.store().Set(cv.key(m), "", 0)
+}
+
+func (cv *Conversation) State(m Message) (string, error) {
+	return cv.store().Get(cv.key(m))
+}
+
+func (cv *Conversation) Middleware(next Handler) Handler {
+	return func(ctx context.Context, u Update) error {
+		if u.Message == nil {
+			return next(ctx, u)
+		}
+		key := cv.key(*u.Message)
+		state, err := cv.store().Get(key)
+		if err != nil {
+			return err
+		}
+		fn, ok := cv.state(state)
+		if !ok {
+			return next(ctx, u)
+		}
+		state, err = fn(ctx, *u.Message)
+		if err != nil {
+			return err
+		}
+		return cv.store().Set(key, state, cv.Timeout)
+	}
+}
+
+func (cv *Conversation) key(m Message) int64 {
+	if cv.ByUser {
+		return m.From.ID
+	}
+	return m.Chat.ID
+}
+
+func (cv *Conversation) state(state string) (StateFunc, bool) {
+	cv.mu.Lock()
+	defer cv.mu.Unlock()
+	fn, ok := cv.states[state]
+	return fn, ok
+}
+
+func (cv *Conversation) store() StateStore {
+	cv.mu.Lock()
+	defer cv.mu.Unlock()
+	if cv.Store == nil {
+		cv.Store = &MemoryStateStore{}
+	}
+	return cv.Store
+}
@@ -0,0 +1,73 @@
+package telegram
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// ErrContinue can be returned by handlers, including command, topic and callback
+// handlers, to pass the update on to the next handler instead of ending its
+// dispatch, e.g. from an analytics handler registered before the actual one.
+var ErrContinue = errors.New("continue dispatch")
+
+// ErrDuplicate is returned for sends suppressed by SendDebounce.
+var ErrDuplicate = errors.New("duplicate send suppressed")
+
+type APIError struct {
+	Method      string
+	ErrorCode   int
+	Description string
+	Parameters  ResponseParameters
+	data        interface{}
+}
+
+func (e *APIError) Error() string {
+	return fmt.Sprintf("%s (%d) (%s: %s)", e.Description, e.ErrorCode, e.Method, prettyPrintJSON(e.data))
+}
+
+func (e *APIError) NeedsAdmin() bool {
+	return e.contains("need administrator rights", "not enough rights", "have no rights")
+}
+
+func (e *APIError) ChatNotFound() bool {
+	return e.ErrorCode == 400 && e.contains("chat not found")
+}
+
+func (e *APIError) NotMember() bool {
+	return e.ErrorCode == 403 && e.contains("is not a member", "join the chat", "kicked from")
+}
+
+// IsBlocked reports whether err is an APIError because the user blocked the bot
+// or deleted their account.
+func IsBlocked(err error) bool {
+	e := (*APIError)(nil)
+	return errors.As(err, &e) && e.ErrorCode == 403 && e.contains("bot was blocked by the user", "user is deactivated")
+}
+
+// IsNotModified reports whether err is an APIError because an edit didn't change
+// the message, which is usually safe to ignore.
+func IsNotModified(err error) bool {
+	e := (*APIError)(nil)
+	return errors.As(err, &e) && e.ErrorCode == 400 && e.contains("message is not modified")
+}
+
+func (e *APIError) contains(substrings ...string) bool {
+	description := strings.ToLower(e.Description)
+	for _, s := range substrings {
+		if strings.Contains(description, s) {
+			return true
+		}
+	}
+	return false
+}
+
+type PanicError struct {
+	Value  interface{}
+	Update string
+	Stack  string
+}
+
+func (e *PanicError) Error() string {
+	return fmt.Sprintf("handler panicked: %v\nupdate: %s\n%s", e.Value, e.Update, e.Stack)
+}
@@ -0,0 +1,39 @@
+package telegram_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/niklasfasching/telegram"
+	"github.com/niklasfasching/telegram/telegramtest"
+)
+
+func TestAPIErrorClassification(t *testing.T) {
+	tests := []struct {
+		code                                int
+		description                         string
+		needsAdmin, chatNotFound, notMember bool
+	}{
+		{400, "Bad Request: not enough rights to send text messages to the chat", true, false, false},
+		{400, "Bad Request: need administrator rights in the channel chat", true, false, false},
+		{400, "Bad Request: chat not found", false, true, false},
+		{403, "Forbidden: bot is not a member of the channel chat", false, false, true},
+		{403, "Forbidden: you must join the chat first", false, false, true},
+		{403, "Forbidden: bot was kicked from the group chat", false, false, true},
+		{400, "Bad Request: message text is empty", false, false, false},
+	}
+	s := telegramtest.NewServer()
+	defer s.Close()
+	c := s.Connection()
+	for _, test := range tests {
+		s.RespondError("sendMessage", test.code, test.description)
+		_, err := c.SendMessage(1, "hi")
+		e := (*telegram.APIError)(nil)
+		if !errors.As(err, &e) {
+			t.Fatalf("%s: expected an APIError, got %v", test.description, err)
+		}
+		if e.NeedsAdmin() != test.needsAdmin || e.ChatNotFound() != test.chatNotFound || e.NotMember() != test.notMember {
+			t.Errorf("%s: got NeedsAdmin=%v ChatNotFound=%v NotMember=%v", test.description, e.NeedsAdmin(), e.ChatNotFound(), e.NotMember())
+		}
+	}
+}
@@ -0,0 +1,12 @@
+package telegram
+
+import (
+	"context"
+	"time"
+)
+
+// SetClock makes the pacing and the retry backoff of c use now and sleep, so
+// tests don't have to wait in real time.
+func SetClock(c *Connection, now func() time.Time, sleep func(context.Context, time.Duration) error) {
+	c.clock, c.sleeper = now, sleep
+}
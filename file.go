@@ -0,0 +1,180 @@
+package telegram
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+)
+
+const (
+	StickerFormatWebP = "webp"
+	StickerFormatTGS  = "tgs"
+	StickerFormatWebM = "webm"
+)
+
+// Size limits of the Bot API. A local Bot API server has no download limit and
+// accepts uploads up to MaxLocalUploadSize.
+const (
+	MaxDownloadSize    = 20 << 20
+	MaxUploadSize      = 50 << 20
+	MaxLocalUploadSize = 2000 << 20
+)
+
+var ErrFileTooBig = errors.New("file is too big to download via the bot api")
+
+var ErrUploadTooBig = errors.New("file is too big to upload via the bot api")
+
+// LocalFile is an InputFile referring to an absolute path on the machine running
+// the Bot API server. In LocalMode the server reads the file itself, so large
+// files aren't uploaded again; otherwise the file is opened and uploaded, as an
+// attachment when it is the media or cover of an InputMedia.
+type LocalFile string
+
+func (c *Connection) openLocalFiles(params map[string]interface{}) (func(), error) {
+	files := []*os.File{}
+	closeFiles := func() {
+		for _, f := range files {
+			f.Close()
+		}
+	}
+	open := func(path LocalFile) (interface{}, error) {
+		if c.LocalMode {
+			return "file://" + string(path), nil
+		}
+		f, err := os.Open(string(path))
+		if err == nil {
+			files = append(files, f)
+		}
+		return f, err
+	}
+	for k, v := range params {
+		if path, ok := v.(LocalFile); ok {
+			f, err := open(path)
+			if err != nil {
+				closeFiles()
+				return nil, err
+			}
+			if k == "cover" {
+				f = attach(params, k, f)
+			}
+			params[k] = f
+		}
+	}
+	items := []map[string]interface{}{}
+	for i, item := range mediaItems(params) {
+		copied := map[string]interface{}{}
+		for k, v := range item {
+			if path, ok := v.(LocalFile); ok {
+				f, err := open(path)
+				if err != nil {
+					closeFiles()
+					return nil, err
+				}
+				v = attach(params, fmt.Sprintf("local_%d_%s", i, k), f)
+			}
+			copied[k] = v
+		}
+		items = append(items, copied)
+	}
+	if _, ok := params["media"].(map[string]interface{}); ok {
+		params["media"] = items[0]
+	} else if len(items) != 0 {
+		params["media"] = items
+	}
+	if err := c.checkUploadSizes(params); err != nil {
+		closeFiles()
+		return nil, err
+	}
+	return closeFiles, nil
+}
+
+func (c *Connection) checkUploadSizes(params map[string]interface{}) error {
+	limit := int64(MaxUploadSize)
+	if c.LocalMode {
+		limit = MaxLocalUploadSize
+	}
+	for _, v := range params {
+		f, ok := v.(*os.File)
+		if !ok {
+			continue
+		}
+		info, err := f.Stat()
+		if err != nil {
+			return err
+		} else if info.Mode().IsRegular() && info.Size() > limit {
+			return fmt.Errorf("%w: %s (%d bytes)", ErrUploadTooBig, f.Name(), info.Size())
+		}
+	}
+	return nil
+}
+
+func (c *Connection) GetFile(fileID string) (File, error) {
+	file := File{}
+	err := c.Call("getFile", map[string]interface{}{"file_id": fileID}, &file)
+	return file, err
+}
+
+func (c *Connection) Download(fileID string) (io.ReadCloser, error) {
+	file, err := c.GetFile(fileID)
+	if apiErr := (*APIError)(nil); errors.As(err, &apiErr) && apiErr.contains("file is too big") {
+		return nil, fmt.Errorf("%w: %s (%s)", ErrFileTooBig, fileID, apiErr.Description)
+	} else if err != nil {
+		return nil, err
+	}
+	return c.DownloadFile(file)
+}
+
+// DownloadFile downloads file from the Bot API server. In LocalMode the server
+// returns absolute paths on its own disk, those files are opened directly.
+func (c *Connection) DownloadFile(file File) (io.ReadCloser, error) {
+	if c.LocalMode && filepath.IsAbs(file.FilePath) {
+		return os.Open(file.FilePath)
+	} else if file.FileSize > MaxDownloadSize && !c.LocalMode {
+		return nil, fmt.Errorf("%w: %s (%d bytes)", ErrFileTooBig, file.FileID, file.FileSize)
+	} else if file.FilePath == "" {
+		return nil, fmt.Errorf("file %s has no file_path", file.FileID)
+	}
+	req, err := c.newRequest(context.Background(), "GET", fmt.Sprintf("%s/file/bot%s/%s", c.baseURL(), c.Token, file.FilePath), nil)
+	if err != nil {
+		return nil, err
+	}
+	res, err := c.client().Do(req)
+	if err != nil {
+		return nil, err
+	}
+	if res.StatusCode != http.StatusOK {
+		res.Body.Close()
+		return nil, fmt.Errorf("download %s: %s", file.FilePath, res.Status)
+	}
+	return res.Body, nil
+}
+
+func (c *Connection) DownloadSticker(sticker Sticker) (io.ReadCloser, string, error) {
+	file, err := c.GetFile(sticker.FileID)
+	if err != nil {
+		return nil, "", err
+	}
+	r, err := c.DownloadFile(file)
+	return r, sticker.Format(), err
+}
+
+func (c *Connection) GetCustomEmojiStickers(customEmojiIDs []string) ([]Sticker, error) {
+	stickers := []Sticker{}
+	err := c.Call("getCustomEmojiStickers", map[string]interface{}{"custom_emoji_ids": customEmojiIDs}, &stickers)
+	return stickers, err
+}
+
+func (s Sticker) Format() string {
+	switch {
+	case s.IsAnimated:
+		return StickerFormatTGS
+	case s.IsVideo:
+		return StickerFormatWebM
+	default:
+		return StickerFormatWebP
+	}
+}
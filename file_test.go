@@ -0,0 +1,88 @@
+package telegram_test
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"reflect"
+	"testing"
+
+	"github.com/niklasfasching/telegram"
+	"github.com/niklasfasching/telegram/telegramtest"
+)
+
+func TestDownloadSticker(t *testing.T) {
+	s := telegramtest.NewServer()
+	defer s.Close()
+	c := s.Connection()
+	c.LocalMode = true
+	files := writeFiles(t, "sticker")
+	s.Respond("getFile", telegram.File{FileID: "s", FilePath: string(files[0])})
+	tests := []struct {
+		sticker telegram.Sticker
+		format  string
+	}{
+		{telegram.Sticker{FileID: "s"}, telegram.StickerFormatWebP},
+		{telegram.Sticker{FileID: "s", IsAnimated: true}, telegram.StickerFormatTGS},
+		{telegram.Sticker{FileID: "s", IsVideo: true}, telegram.StickerFormatWebM},
+	}
+	for _, test := range tests {
+		r, format, err := c.DownloadSticker(test.sticker)
+		if err != nil {
+			t.Fatal(err)
+		}
+		bs, err := ioutil.ReadAll(r)
+		r.Close()
+		if err != nil || string(bs) != "sticker" {
+			t.Errorf("unexpected content %q: %v", bs, err)
+		}
+		if format != test.format {
+			t.Errorf("got format %s, want %s", format, test.format)
+		}
+	}
+}
+
+func TestGetCustomEmojiStickers(t *testing.T) {
+	s := telegramtest.NewServer()
+	defer s.Close()
+	s.Respond("getCustomEmojiStickers", json.RawMessage(`[{"file_id": "a", "type": "custom_emoji", "emoji": "😀", "custom_emoji_id": "1", "is_animated": true}, {"file_id": "b", "type": "custom_emoji", "emoji": "🔥", "custom_emoji_id": "2"}]`))
+	stickers, err := s.Connection().GetCustomEmojiStickers([]string{"1", "2"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []telegram.Sticker{
+		{FileID: "a", Type: "custom_emoji", Emoji: "😀", CustomEmojiID: "1", IsAnimated: true},
+		{FileID: "b", Type: "custom_emoji", Emoji: "🔥", CustomEmojiID: "2"},
+	}
+	if !reflect.DeepEqual(stickers, want) {
+		t.Errorf("got %#v, want %#v", stickers, want)
+	}
+	if got := s.Calls("getCustomEmojiStickers")[0].Params["custom_emoji_ids"]; got != `["1","2"]` {
+		t.Errorf("got custom_emoji_ids %s", got)
+	}
+}
+
+func TestLocalModeUpload(t *testing.T) {
+	s := telegramtest.NewServer()
+	defer s.Close()
+	s.Respond("sendDocument", telegram.Message{ID: 1})
+	c := s.Connection()
+	files := writeFiles(t, "document")
+	for _, localMode := range []bool{false, true} {
+		c.LocalMode = localMode
+		if _, multipart, err := c.RenderRequest("sendDocument", map[string]interface{}{"chat_id": 1, "document": files[0]}); err != nil {
+			t.Fatal(err)
+		} else if multipart == localMode {
+			t.Errorf("LocalMode %v: got multipart %v", localMode, multipart)
+		}
+		if _, err := c.SendDocument(1, files[0]); err != nil {
+			t.Fatal(err)
+		}
+	}
+	calls := s.Calls("sendDocument")
+	if string(calls[0].Files["document"]) != "document" {
+		t.Errorf("expected an upload without LocalMode, got %v", calls[0])
+	}
+	if len(calls[1].Files) != 0 || calls[1].Params["document"] != "file://"+string(files[0]) {
+		t.Errorf("expected a file:// path in LocalMode, got %v", calls[1])
+	}
+}
@@ -0,0 +1,68 @@
+package telegram
+
+import (
+	"context"
+	"encoding/json"
+)
+
+type ChatTypeFilter struct {
+	Types  []string
+	Notice string
+}
+
+var (
+	PrivateOnly  = ChatTypeFilter{Types: []string{"private"}}
+	GroupsOnly   = ChatTypeFilter{Types: []string{"group", "supergroup"}}
+	ChannelsOnly = ChatTypeFilter{Types: []string{"channel"}}
+)
+
+func (f ChatTypeFilter) Allows(chatType string) bool {
+	for _, t := range f.Types {
+		if t == chatType {
+			return true
+		}
+	}
+	return false
+}
+
+func (f ChatTypeFilter) WithNotice(notice string) ChatTypeFilter {
+	f.Notice = notice
+	return f
+}
+
+func (f ChatTypeFilter) Middleware(c *Connection) CommandMiddleware {
+	return func(next CommandFunc) CommandFunc {
+		return func(ctx context.Context, m Message, args []string) error {
+			if !f.Allows(m.Chat.Type) {
+				return f.reject(ctx, c, m)
+			}
+			return next(ctx, m, args)
+		}
+	}
+}
+
+func (f ChatTypeFilter) reject(ctx context.Context, c *Connection, m Message) error {
+	if f.Notice == "" || m.Chat.Type == "channel" {
+		return nil
+	}
+	return c.CallContext(ctx, "sendMessage", map[string]interface{}{"chat_id": m.Chat.ID, "text": f.Notice}, nil)
+}
+
+func (c *Connection) filterChatType(ctx context.Context, update map[string]json.RawMessage) (bool, error) {
+	if c.ChatTypes == nil {
+		return true, nil
+	}
+	u, err := decodeUpdate(update)
+	if err != nil {
+		return false, err
+	}
+	chat, ok := u.EffectiveChat()
+	if !ok || c.ChatTypes.Allows(chat.Type) {
+		return true, nil
+	}
+	c.debugLog(tracePrefix(ctx, "filtered"), []byte(prettyPrintJSON(update)))
+	if m, ok := u.EffectiveMessage(); ok && u.CallbackQuery == nil {
+		return false, c.ChatTypes.reject(ctx, c, *m)
+	}
+	return false, nil
+}
@@ -0,0 +1,56 @@
+package telegram_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/niklasfasching/telegram"
+	"github.com/niklasfasching/telegram/telegramtest"
+)
+
+func TestChatTypeFilter(t *testing.T) {
+	s := telegramtest.NewServer()
+	defer s.Close()
+	c := s.Connection()
+	filter := telegram.PrivateOnly
+	c.ChatTypes = &filter
+	handled := 0
+	c.Handle("message", func(m telegram.Message) error {
+		handled++
+		return nil
+	})
+	h := &telegramtest.Harness{Connection: c}
+	if d := h.Inject(`{"update_id": 1, "message": {"message_id": 1, "chat": {"id": -100, "type": "group"}, "text": "hi"}}`); d.Err != nil || d.Route != "filtered" {
+		t.Errorf("expected the group message to be filtered, got %#v", d)
+	}
+	if d := h.Inject(`{"update_id": 2, "message": {"message_id": 2, "chat": {"id": 1, "type": "private"}, "text": "hi"}}`); d.Err != nil || d.Route != "message" {
+		t.Errorf("expected the private message to be handled, got %#v", d)
+	}
+	if handled != 1 || len(s.SentMessages()) != 0 {
+		t.Errorf("expected one handled message and no notice, got %d and %v", handled, s.SentMessages())
+	}
+
+	filter = telegram.PrivateOnly.WithNotice("private chats only")
+	h.Inject(`{"update_id": 3, "message": {"message_id": 3, "chat": {"id": -100, "type": "supergroup"}, "text": "hi"}}`)
+	h.Inject(`{"update_id": 4, "channel_post": {"message_id": 4, "chat": {"id": -200, "type": "channel"}, "text": "hi"}}`)
+	if texts := s.SentMessages(); handled != 1 || len(texts) != 1 || texts[0] != "private chats only" {
+		t.Errorf("expected a single notice, got %v", texts)
+	}
+}
+
+func TestChatTypeFilterMiddleware(t *testing.T) {
+	s := telegramtest.NewServer()
+	defer s.Close()
+	c := s.Connection()
+	chats := []int64{}
+	c.HandleCommand("stats", func(ctx context.Context, m telegram.Message, args []string) error {
+		chats = append(chats, m.Chat.ID)
+		return nil
+	}, telegram.GroupsOnly.Middleware(c))
+	h := &telegramtest.Harness{Connection: c}
+	h.Inject(`{"update_id": 1, "message": {"message_id": 1, "chat": {"id": 1, "type": "private"}, "text": "/stats"}}`)
+	h.Inject(`{"update_id": 2, "message": {"message_id": 2, "chat": {"id": -100, "type": "group"}, "text": "/stats"}}`)
+	if len(chats) != 1 || chats[0] != -100 {
+		t.Errorf("expected only the group command to be handled, got %v", chats)
+	}
+}
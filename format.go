@@ -0,0 +1,300 @@
+package telegram
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"unicode/utf16"
+)
+
+type ParseMode string
+
+const (
+	ParseModeNone       ParseMode = ""
+	ParseModeMarkdown   ParseMode = "Markdown"
+	ParseModeMarkdownV2 ParseMode = "MarkdownV2"
+	ParseModeHTML       ParseMode = "HTML"
+)
+
+const MaxMessageLength = 4096
+
+var markdownV2Replacer = strings.NewReplacer(
+	`\`, `\\`, "_", `\_`, "*", `\*`, "[", `\[`, "]", `\]`, "(", `\(`, ")", `\)`, "~", `\~`, "`", "\\`",
+	">", `\>`, "#", `\#`, "+", `\+`, "-", `\-`, "=", `\=`, "|", `\|`, "{", `\{`, "}", `\}`, ".", `\.`, "!", `\!`,
+)
+var markdownV2CodeReplacer = strings.NewReplacer(`\`, `\\`, "`", "\\`")
+var markdownV2URLReplacer = strings.NewReplacer(`\`, `\\`, ")", `\)`)
+var htmlReplacer = strings.NewReplacer("&", "&amp;", "<", "&lt;", ">", "&gt;", `"`, "&quot;")
+
+func EscapeMarkdownV2(s string) string { return markdownV2Replacer.Replace(s) }
+
+func EscapeHTML(s string) string { return htmlReplacer.Replace(s) }
+
+// TextBuilder builds formatted text without manual escaping. String renders it
+// in Mode, to be sent with WithParseMode(Mode); PlainText and Entities return it
+// unformatted plus entities, to be sent with WithEntities and no parse mode.
+type TextBuilder struct {
+	Mode     ParseMode
+	text     strings.Builder
+	length   int
+	entities []MessageEntity
+}
+
+func NewTextBuilder(mode ParseMode) *TextBuilder { return &TextBuilder{Mode: mode} }
+
+func (b *TextBuilder) Text(s string) *TextBuilder {
+	b.text.WriteString(s)
+	b.length += UTF16Len(s)
+	return b
+}
+
+func (b *TextBuilder) Bold(s string) *TextBuilder { return b.entity(MessageEntity{Type: "bold"}, s) }
+
+func (b *TextBuilder) Italic(s string) *TextBuilder {
+	return b.entity(MessageEntity{Type: "italic"}, s)
+}
+
+func (b *TextBuilder) Underline(s string) *TextBuilder {
+	return b.entity(MessageEntity{Type: "underline"}, s)
+}
+
+func (b *TextBuilder) Strikethrough(s string) *TextBuilder {
+	return b.entity(MessageEntity{Type: "strikethrough"}, s)
+}
+
+func (b *TextBuilder) Spoiler(s string) *TextBuilder {
+	return b.entity(MessageEntity{Type: "spoiler"}, s)
+}
+
+func (b *TextBuilder) Code(s string) *TextBuilder { return b.entity(MessageEntity{Type: "code"}, s) }
+
+func (b *TextBuilder) Pre(s, language string) *TextBuilder {
+	return b.entity(MessageEntity{Type: "pre", Language: language}, s)
+}
+
+func (b *TextBuilder) Link(s, url string) *TextBuilder {
+	return b.entity(MessageEntity{Type: "text_link", URL: url}, s)
+}
+
+func (b *TextBuilder) Mention(s string, user User) *TextBuilder {
+	return b.entity(MessageEntity{Type: "text_mention", User: &user}, s)
+}
+
+func (b *TextBuilder) PlainText() string { return b.text.String() }
+
+func (b *TextBuilder) Entities() []MessageEntity { return append([]MessageEntity{}, b.entities...) }
+
+func (b *TextBuilder) String() string {
+	m := Message{Text: b.text.String(), Entities: b.entities}
+	switch b.Mode {
+	case ParseModeHTML:
+		return m.AsHTML()
+	case ParseModeMarkdownV2:
+		return m.AsMarkdownV2()
+	}
+	return m.Text
+}
+
+func (b *TextBuilder) entity(e MessageEntity, s string) *TextBuilder {
+	if e.Offset, e.Length = b.length, UTF16Len(s); e.Length != 0 {
+		b.entities = append(b.entities, e)
+	}
+	return b.Text(s)
+}
+
+func WithEntities(entities []MessageEntity) Option {
+	return func(p map[string]interface{}) { p["entities"] = entities }
+}
+
+func (c *Connection) decorate(method string, params map[string]interface{}) error {
+	if method != "sendMessage" && method != "editMessageText" {
+		return nil
+	}
+	mode := c.ParseMode
+	_, hasEntities := params["entities"]
+	if v, ok := params["parse_mode"]; ok {
+		mode = ParseMode(fmt.Sprint(v))
+	} else if hasEntities {
+		mode = ParseModeNone
+	} else if mode != ParseModeNone {
+		params["parse_mode"] = string(mode)
+	}
+	text, ok := params["text"].(string)
+	if c.MessageDecorator == nil || !ok {
+		return nil
+	}
+	text = c.MessageDecorator(text, mode)
+	if n := UTF16Len(text); n > MaxMessageLength {
+		return fmt.Errorf("%s: decorated text is %d characters long (max %d)", method, n, MaxMessageLength)
+	}
+	params["text"] = text
+	return nil
+}
+
+func isParseError(r response) bool {
+	return r.ErrorCode == 400 && strings.Contains(r.Description, "can't parse entities")
+}
+
+func validateEntities(method string, params map[string]interface{}) error {
+	for _, keys := range [][2]string{{"text", "entities"}, {"caption", "caption_entities"}} {
+		entities, ok := params[keys[1]].([]MessageEntity)
+		if !ok {
+			continue
+		}
+		text, _ := params[keys[0]].(string)
+		n := UTF16Len(text)
+		for i, e := range entities {
+			if e.Offset < 0 || e.Length <= 0 || e.Offset+e.Length > n {
+				return fmt.Errorf("%s: %s[%d] (%s, offset %d, length %d) does not fit %s of %d UTF-16 code units",
+					method, keys[1], i, e.Type, e.Offset, e.Length, keys[0], n)
+			}
+		}
+	}
+	return nil
+}
+
+func (m Message) AsHTML() string {
+	return renderEntities(m.Text, m.Entities, htmlTags, func(s string, _ []MessageEntity) string {
+		return htmlReplacer.Replace(s)
+	})
+}
+
+func (m Message) AsMarkdownV2() string {
+	return renderEntities(m.Text, m.Entities, markdownV2Tags, func(s string, active []MessageEntity) string {
+		for _, e := range active {
+			if e.Type == "code" || e.Type == "pre" {
+				return markdownV2CodeReplacer.Replace(s)
+			}
+		}
+		return markdownV2Replacer.Replace(s)
+	})
+}
+
+func htmlTags(e MessageEntity) (string, string) {
+	switch e.Type {
+	case "bold":
+		return "<b>", "</b>"
+	case "italic":
+		return "<i>", "</i>"
+	case "underline":
+		return "<u>", "</u>"
+	case "strikethrough":
+		return "<s>", "</s>"
+	case "spoiler":
+		return "<tg-spoiler>", "</tg-spoiler>"
+	case "code":
+		return "<code>", "</code>"
+	case "pre":
+		if e.Language != "" {
+			return fmt.Sprintf(`<pre><code class="language-%s">`, htmlReplacer.Replace(e.Language)), "</code></pre>"
+		}
+		return "<pre>", "</pre>"
+	case "text_link":
+		return fmt.Sprintf(`<a href="%s">`, htmlReplacer.Replace(e.URL)), "</a>"
+	case "text_mention":
+		if e.User != nil {
+			return fmt.Sprintf(`<a href="tg://user?id=%d">`, e.User.ID), "</a>"
+		}
+	case "custom_emoji":
+		return fmt.Sprintf(`<tg-emoji emoji-id="%s">`, htmlReplacer.Replace(e.CustomEmojiID)), "</tg-emoji>"
+	case "blockquote":
+		return "<blockquote>", "</blockquote>"
+	case "expandable_blockquote":
+		return "<blockquote expandable>", "</blockquote>"
+	}
+	return "", ""
+}
+
+func markdownV2Tags(e MessageEntity) (string, string) {
+	switch e.Type {
+	case "bold":
+		return "*", "*"
+	case "italic":
+		return "_", "_"
+	case "underline":
+		return "__", "__"
+	case "strikethrough":
+		return "~", "~"
+	case "spoiler":
+		return "||", "||"
+	case "code":
+		return "`", "`"
+	case "pre":
+		return "```" + e.Language + "\n", "\n```"
+	case "text_link":
+		return "[", "](" + markdownV2URLReplacer.Replace(e.URL) + ")"
+	case "text_mention":
+		if e.User != nil {
+			return "[", fmt.Sprintf("](tg://user?id=%d)", e.User.ID)
+		}
+	case "custom_emoji":
+		return "![", "](tg://emoji?id=" + markdownV2URLReplacer.Replace(e.CustomEmojiID) + ")"
+	}
+	return "", ""
+}
+
+func renderEntities(text string, entities []MessageEntity, tags func(MessageEntity) (string, string), escape func(string, []MessageEntity) string) string {
+	units := utf16.Encode([]rune(text))
+	entities = append([]MessageEntity{}, entities...)
+	sort.SliceStable(entities, func(i, j int) bool {
+		if entities[i].Offset != entities[j].Offset {
+			return entities[i].Offset < entities[j].Offset
+		}
+		return entities[i].Length > entities[j].Length
+	})
+	positions := []int{0, len(units)}
+	for _, e := range entities {
+		positions = append(positions, e.Offset, e.Offset+e.Length)
+	}
+	sort.Ints(positions)
+	boundaries := []int{}
+	for _, p := range positions {
+		if p >= 0 && p <= len(units) && (len(boundaries) == 0 || boundaries[len(boundaries)-1] != p) {
+			boundaries = append(boundaries, p)
+		}
+	}
+	out, stack, lastTag := strings.Builder{}, []MessageEntity{}, ""
+	writeTag := func(tag string) {
+		// ___ is ambiguous in MarkdownV2, Telegram reads it as underline first.
+		// Adjacent italic and underline tags are separated by \r, which it ignores.
+		if strings.HasPrefix(tag, "_") && strings.HasSuffix(lastTag, "_") {
+			out.WriteString("\r")
+		}
+		out.WriteString(tag)
+		lastTag = tag
+	}
+	for i, p := range boundaries {
+		closeAt, end := len(stack), p == len(units)
+		for j, e := range stack {
+			if e.Offset+e.Length <= p || end {
+				closeAt = j
+				break
+			}
+		}
+		reopen := []MessageEntity{}
+		for len(stack) > closeAt {
+			e := stack[len(stack)-1]
+			stack = stack[:len(stack)-1]
+			_, closeTag := tags(e)
+			writeTag(closeTag)
+			if e.Offset+e.Length > p && !end {
+				reopen = append([]MessageEntity{e}, reopen...)
+			}
+		}
+		for _, e := range entities {
+			if e.Offset == p && e.Length > 0 && !end {
+				reopen = append(reopen, e)
+			}
+		}
+		for _, e := range reopen {
+			openTag, _ := tags(e)
+			writeTag(openTag)
+			stack = append(stack, e)
+		}
+		if !end {
+			out.WriteString(escape(string(utf16.Decode(units[p:boundaries[i+1]])), stack))
+			lastTag = ""
+		}
+	}
+	return out.String()
+}
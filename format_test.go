@@ -0,0 +1,135 @@
+package telegram_test
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/niklasfasching/telegram"
+	"github.com/niklasfasching/telegram/telegramtest"
+)
+
+func TestDefaultParseModeWithEntities(t *testing.T) {
+	c := &telegram.Connection{ParseMode: telegram.ParseModeMarkdownV2}
+	b := telegram.NewTextBuilder(telegram.ParseModeNone).Bold("Hello").Text(", world!")
+	params, _, err := c.RenderRequest("sendMessage", map[string]interface{}{"chat_id": 1, "text": b.PlainText(), "entities": b.Entities()})
+	if err != nil {
+		t.Fatal(err)
+	} else if mode, ok := params["parse_mode"]; ok {
+		t.Errorf("expected no parse_mode alongside entities, got %v", mode)
+	}
+	params, _, err = c.RenderRequest("sendMessage", map[string]interface{}{"chat_id": 1, "text": "*Hello*"})
+	if err != nil {
+		t.Fatal(err)
+	} else if mode := params["parse_mode"]; mode != "MarkdownV2" {
+		t.Errorf("expected the default parse_mode, got %v", mode)
+	}
+}
+
+func TestRenderEntities(t *testing.T) {
+	link := telegram.MessageEntity{Type: "text_link", Offset: 7, Length: 4, URL: "https://example.com/a_(b)"}
+	tests := []struct {
+		name             string
+		message          telegram.Message
+		markdownV2, html string
+	}{
+		{"overlap",
+			telegram.Message{Text: "bold both italic", Entities: []telegram.MessageEntity{{Type: "bold", Offset: 0, Length: 9}, {Type: "italic", Offset: 5, Length: 11}}},
+			"*bold _both_*_ italic_", "<b>bold <i>both</i></b><i> italic</i>"},
+		{"link after emoji",
+			telegram.Message{Text: "see 👍 docs!", Entities: []telegram.MessageEntity{link}},
+			`see 👍 [docs](https://example.com/a_(b\))\!`, `see 👍 <a href="https://example.com/a_(b)">docs</a>!`},
+		{"italic underline",
+			telegram.Message{Text: "iu", Entities: []telegram.MessageEntity{{Type: "italic", Offset: 0, Length: 2}, {Type: "underline", Offset: 0, Length: 2}}},
+			"_\r__iu__\r_", "<i><u>iu</u></i>"},
+		{"underline then italic",
+			telegram.Message{Text: "a_b", Entities: []telegram.MessageEntity{{Type: "underline", Offset: 0, Length: 2}, {Type: "italic", Offset: 2, Length: 1}}},
+			`__a\___` + "\r" + `_b_`, "<u>a_</u><i>b</i>"},
+	}
+	for _, test := range tests {
+		if got := test.message.AsMarkdownV2(); got != test.markdownV2 {
+			t.Errorf("%s: AsMarkdownV2() = %q, want %q", test.name, got, test.markdownV2)
+		}
+		if got := test.message.AsHTML(); got != test.html {
+			t.Errorf("%s: AsHTML() = %q, want %q", test.name, got, test.html)
+		}
+	}
+}
+
+func TestMessageDecorator(t *testing.T) {
+	s := telegramtest.NewServer()
+	defer s.Close()
+	c := s.Connection()
+	c.ParseMode = telegram.ParseModeMarkdownV2
+	modes := []telegram.ParseMode{}
+	c.MessageDecorator = func(text string, mode telegram.ParseMode) string {
+		modes = append(modes, mode)
+		if mode == telegram.ParseModeMarkdownV2 {
+			return text + "\n" + telegram.EscapeMarkdownV2("-- sent by bot.")
+		}
+		return text + "\n-- sent by bot."
+	}
+	if _, err := c.SendMessage(1, "*hi*"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := c.SendMessage(1, "hi", telegram.WithParseMode(telegram.ParseModeHTML)); err != nil {
+		t.Fatal(err)
+	}
+	if texts, want := s.SentMessages(), []string{"*hi*\n\\-\\- sent by bot\\.", "hi\n-- sent by bot."}; !reflect.DeepEqual(texts, want) {
+		t.Errorf("got texts %q, want %q", texts, want)
+	}
+	if want := []telegram.ParseMode{telegram.ParseModeMarkdownV2, telegram.ParseModeHTML}; !reflect.DeepEqual(modes, want) {
+		t.Errorf("got modes %v, want %v", modes, want)
+	}
+	if _, err := c.SendMessage(1, strings.Repeat("a", telegram.MaxMessageLength-5)); err == nil || !strings.Contains(err.Error(), "decorated text") {
+		t.Errorf("expected the footer to exceed the limit, got %v", err)
+	} else if n := len(s.SentMessages()); n != 2 {
+		t.Errorf("expected the over-limit message not to be sent, got %d messages", n)
+	}
+}
+
+func TestValidateEntities(t *testing.T) {
+	s := telegramtest.NewServer()
+	defer s.Close()
+	c := s.Connection()
+	valid := []telegram.MessageEntity{{Type: "bold", Offset: 3, Length: 2}}
+	if _, err := c.SendMessage(1, "👍 hi", telegram.WithEntities(valid)); err != nil {
+		t.Fatal(err)
+	}
+	for _, e := range []telegram.MessageEntity{{Type: "bold", Offset: 3, Length: 3}, {Type: "italic", Offset: -1, Length: 1}} {
+		entities := append(valid, e)
+		if _, err := c.SendMessage(1, "👍 hi", telegram.WithEntities(entities)); err == nil || !strings.Contains(err.Error(), "entities[1] ("+e.Type) {
+			t.Errorf("expected %v to be rejected, got %v", e, err)
+		}
+	}
+	if n := len(s.SentMessages()); n != 1 {
+		t.Errorf("expected invalid entities not to be sent, got %d messages", n)
+	}
+}
+
+func TestPlainTextFallback(t *testing.T) {
+	s := telegramtest.NewServer()
+	defer s.Close()
+	s.RespondFunc("sendMessage", func(call telegramtest.Call) interface{} {
+		if call.Params["parse_mode"] != "" {
+			return telegramtest.Error{Code: 400, Description: "Bad Request: can't parse entities: Character '!' is reserved"}
+		}
+		return telegram.Message{ID: 1, Text: call.Params["text"]}
+	})
+	logger, c := &testLogger{}, s.Connection()
+	c.Logger, c.ParseMode = logger, telegram.ParseModeMarkdownV2
+	if _, err := c.SendMessage(1, "hi!"); err == nil || !strings.Contains(err.Error(), "can't parse entities") {
+		t.Fatalf("expected the parse error without PlainTextFallback, got %v", err)
+	}
+	c.PlainTextFallback = true
+	if m, err := c.SendMessage(1, "hi!"); err != nil || m.Text != "hi!" {
+		t.Fatalf("expected the plain text retry to succeed, got %v %v", m, err)
+	}
+	calls := s.Calls("sendMessage")
+	if len(calls) != 3 || calls[1].Params["parse_mode"] != "MarkdownV2" || calls[2].Params["parse_mode"] != "" {
+		t.Errorf("expected a single retry without parse_mode, got %v", calls)
+	}
+	if !logger.contains("retrying as plain text") {
+		t.Errorf("expected a warning, got %v", logger.lines)
+	}
+}
@@ -0,0 +1,75 @@
+package telegram
+
+// Colors Telegram accepts for the icon of a new forum topic.
+const (
+	ForumTopicColorBlue   = 0x6FB9F0
+	ForumTopicColorYellow = 0xFFD67E
+	ForumTopicColorPurple = 0xCB86DB
+	ForumTopicColorGreen  = 0x8EEE98
+	ForumTopicColorPink   = 0xFF93B2
+	ForumTopicColorRed    = 0xFB6F5F
+)
+
+type ForumTopic struct {
+	MessageThreadID   int    `json:"message_thread_id"`
+	Name              string `json:"name"`
+	IconColor         int    `json:"icon_color"`
+	IconCustomEmojiID string `json:"icon_custom_emoji_id"`
+}
+
+type ForumTopicCreated struct {
+	Name              string `json:"name"`
+	IconColor         int    `json:"icon_color"`
+	IconCustomEmojiID string `json:"icon_custom_emoji_id"`
+}
+
+// ForumTopicEdited only contains what changed; an empty IconCustomEmojiID that
+// is set means the icon was removed.
+type ForumTopicEdited struct {
+	Name              string  `json:"name"`
+	IconCustomEmojiID *string `json:"icon_custom_emoji_id"`
+}
+
+type ForumTopicClosed struct{}
+
+type ForumTopicReopened struct{}
+
+// WithMessageThreadID sends to a forum topic; it works with all send methods.
+func WithMessageThreadID(threadID int) Option {
+	return func(p map[string]interface{}) { p["message_thread_id"] = threadID }
+}
+
+func WithIconColor(color int) Option {
+	return func(p map[string]interface{}) { p["icon_color"] = color }
+}
+
+func WithIconCustomEmojiID(customEmojiID string) Option {
+	return func(p map[string]interface{}) { p["icon_custom_emoji_id"] = customEmojiID }
+}
+
+func (c *Connection) CreateForumTopic(chatID int64, name string, opts ...Option) (ForumTopic, error) {
+	t := ForumTopic{}
+	err := c.Call("createForumTopic", applyOptions(map[string]interface{}{"chat_id": chatID, "name": name}, opts), &t)
+	return t, err
+}
+
+// EditForumTopic changes the name (WithName) and/or icon (WithIconCustomEmojiID)
+// of a topic.
+func (c *Connection) EditForumTopic(chatID int64, threadID int, opts ...Option) error {
+	return c.Call("editForumTopic", applyOptions(map[string]interface{}{
+		"chat_id":           chatID,
+		"message_thread_id": threadID,
+	}, opts), nil)
+}
+
+func (c *Connection) CloseForumTopic(chatID int64, threadID int) error {
+	return c.Call("closeForumTopic", map[string]interface{}{"chat_id": chatID, "message_thread_id": threadID}, nil)
+}
+
+func (c *Connection) ReopenForumTopic(chatID int64, threadID int) error {
+	return c.Call("reopenForumTopic", map[string]interface{}{"chat_id": chatID, "message_thread_id": threadID}, nil)
+}
+
+func (c *Connection) DeleteForumTopic(chatID int64, threadID int) error {
+	return c.Call("deleteForumTopic", map[string]interface{}{"chat_id": chatID, "message_thread_id": threadID}, nil)
+}
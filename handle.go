@@ -0,0 +1,136 @@
+package telegram
+
+import (
+	"context"
+	"encoding/json"
+)
+
+// The On methods register typed handlers for a single update kind, so unlike
+// with Handle a wrong handler signature is a compile error. They are called
+// directly rather than via reflection.
+
+func (c *Connection) OnMessage(fn func(Message) error) {
+	c.on("message", func(_ context.Context, data json.RawMessage) error {
+		v := Message{}
+		return decode(data, &v, func() error { return fn(v) })
+	})
+}
+
+func (c *Connection) OnEditedMessage(fn func(Message) error) {
+	c.on("edited_message", func(_ context.Context, data json.RawMessage) error {
+		v := Message{}
+		return decode(data, &v, func() error { return fn(v) })
+	})
+}
+
+func (c *Connection) OnChannelPost(fn func(Message) error) {
+	c.on("channel_post", func(_ context.Context, data json.RawMessage) error {
+		v := Message{}
+		return decode(data, &v, func() error { return fn(v) })
+	})
+}
+
+func (c *Connection) OnEditedChannelPost(fn func(Message) error) {
+	c.on("edited_channel_post", func(_ context.Context, data json.RawMessage) error {
+		v := Message{}
+		return decode(data, &v, func() error { return fn(v) })
+	})
+}
+
+func (c *Connection) OnMessageReaction(fn func(MessageReactionUpdated) error) {
+	c.on("message_reaction", func(_ context.Context, data json.RawMessage) error {
+		v := MessageReactionUpdated{}
+		return decode(data, &v, func() error { return fn(v) })
+	})
+}
+
+func (c *Connection) OnMessageReactionCount(fn func(MessageReactionCountUpdated) error) {
+	c.on("message_reaction_count", func(_ context.Context, data json.RawMessage) error {
+		v := MessageReactionCountUpdated{}
+		return decode(data, &v, func() error { return fn(v) })
+	})
+}
+
+func (c *Connection) OnInlineQuery(fn func(InlineQuery) error) {
+	c.on("inline_query", func(_ context.Context, data json.RawMessage) error {
+		v := InlineQuery{}
+		return decode(data, &v, func() error { return fn(v) })
+	})
+}
+
+func (c *Connection) OnChosenInlineResult(fn func(ChosenInlineResult) error) {
+	c.on("chosen_inline_result", func(_ context.Context, data json.RawMessage) error {
+		v := ChosenInlineResult{}
+		return decode(data, &v, func() error { return fn(v) })
+	})
+}
+
+func (c *Connection) OnCallbackQuery(fn func(CallbackQuery) error) {
+	c.on("callback_query", func(_ context.Context, data json.RawMessage) error {
+		v := CallbackQuery{}
+		return decode(data, &v, func() error { return fn(v) })
+	})
+}
+
+func (c *Connection) OnShippingQuery(fn func(ShippingQuery) error) {
+	c.on("shipping_query", func(_ context.Context, data json.RawMessage) error {
+		v := ShippingQuery{}
+		return decode(data, &v, func() error { return fn(v) })
+	})
+}
+
+func (c *Connection) OnPreCheckoutQuery(fn func(PreCheckoutQuery) error) {
+	c.on("pre_checkout_query", func(_ context.Context, data json.RawMessage) error {
+		v := PreCheckoutQuery{}
+		return decode(data, &v, func() error { return fn(v) })
+	})
+}
+
+func (c *Connection) OnPoll(fn func(Poll) error) {
+	c.on("poll", func(_ context.Context, data json.RawMessage) error {
+		v := Poll{}
+		return decode(data, &v, func() error { return fn(v) })
+	})
+}
+
+func (c *Connection) OnPollAnswer(fn func(PollAnswer) error) {
+	c.on("poll_answer", func(_ context.Context, data json.RawMessage) error {
+		v := PollAnswer{}
+		return decode(data, &v, func() error { return fn(v) })
+	})
+}
+
+func (c *Connection) OnChatBoost(fn func(ChatBoostUpdated) error) {
+	c.on("chat_boost", func(_ context.Context, data json.RawMessage) error {
+		v := ChatBoostUpdated{}
+		return decode(data, &v, func() error { return fn(v) })
+	})
+}
+
+func (c *Connection) OnRemovedChatBoost(fn func(ChatBoostRemoved) error) {
+	c.on("removed_chat_boost", func(_ context.Context, data json.RawMessage) error {
+		v := ChatBoostRemoved{}
+		return decode(data, &v, func() error { return fn(v) })
+	})
+}
+
+func (c *Connection) OnMyChatMember(fn func(ChatMemberUpdated) error) {
+	c.on("my_chat_member", func(_ context.Context, data json.RawMessage) error {
+		v := ChatMemberUpdated{}
+		return decode(data, &v, func() error { return fn(v) })
+	})
+}
+
+func (c *Connection) OnChatMember(fn func(ChatMemberUpdated) error) {
+	c.on("chat_member", func(_ context.Context, data json.RawMessage) error {
+		v := ChatMemberUpdated{}
+		return decode(data, &v, func() error { return fn(v) })
+	})
+}
+
+func decode(data json.RawMessage, v interface{}, fn func() error) error {
+	if err := unmarshalJSON(data, v); err != nil {
+		return err
+	}
+	return fn()
+}
@@ -0,0 +1,111 @@
+package telegram_test
+
+import (
+	"context"
+	"fmt"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/niklasfasching/telegram"
+	"github.com/niklasfasching/telegram/telegramtest"
+)
+
+func TestOnHandlers(t *testing.T) {
+	c, got := &telegram.Connection{}, []string{}
+	c.OnMessage(func(m telegram.Message) error {
+		got = append(got, "message:"+m.Text)
+		return telegram.ErrContinue
+	})
+	c.Handle("message", func(m telegram.Message) error {
+		got = append(got, "handle:"+m.Text)
+		return nil
+	})
+	c.OnCallbackQuery(func(q telegram.CallbackQuery) error {
+		got = append(got, "callback:"+q.Data)
+		return nil
+	})
+	c.OnChatMember(func(u telegram.ChatMemberUpdated) error {
+		got = append(got, "chat_member:"+u.NewChatMember.Status)
+		return nil
+	})
+	h := &telegramtest.Harness{Connection: c}
+	for _, u := range []string{
+		`{"update_id": 1, "message": {"message_id": 1, "chat": {"id": 1}, "text": "hi"}}`,
+		`{"update_id": 2, "callback_query": {"id": "q", "from": {"id": 1}, "data": "yes"}}`,
+		`{"update_id": 3, "chat_member": {"chat": {"id": 1}, "from": {"id": 1}, "new_chat_member": {"status": "member", "user": {"id": 2}}}}`,
+		`{"update_id": 4, "message": {"message_id": "not a number"}}`,
+	} {
+		h.Inject(u)
+	}
+	want := []string{"message:hi", "handle:hi", "callback:yes", "chat_member:member"}
+	if len(got) != len(want) {
+		t.Fatalf("got %v, want %v", got, want)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("got %v, want %v", got, want)
+		}
+	}
+	if d := h.Dispatches()[3]; d.Err == nil || d.Route != "message" {
+		t.Errorf("expected a decode error for the invalid message, got %+v", d)
+	}
+}
+
+func TestConcurrentDispatchAndRegistration(t *testing.T) {
+	s := telegramtest.NewServer()
+	defer s.Close()
+	for i := 1; i <= 100; i++ {
+		s.InjectUpdate(fmt.Sprintf(`{"message": {"message_id": %d, "chat": {"id": 1}, "text": "/ping"}}`, i))
+	}
+	c := s.Connection()
+	c.Concurrency = 8
+	var polled, hooked, late int64
+	c.HandleCommand("ping", func(ctx context.Context, m telegram.Message, args []string) error {
+		atomic.AddInt64(&polled, 1)
+		return nil
+	})
+	c.Handle("channel_post", func(m telegram.Message) error {
+		atomic.AddInt64(&hooked, 1)
+		return nil
+	})
+	hook := c.WebhookHandler()
+	go c.Start()
+	defer c.StopAndWait()
+	wg := sync.WaitGroup{}
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			c.HandleCommand(fmt.Sprintf("cmd%d", i), func(context.Context, telegram.Message, []string) error { return nil })
+			c.Handle("edited_message", func(m telegram.Message) error {
+				atomic.AddInt64(&late, 1)
+				return nil
+			})
+			for j := 0; j < 10; j++ {
+				_ = c.User().Username
+				update := fmt.Sprintf(`{"update_id": %d, "channel_post": {"message_id": %d, "chat": {"id": -100, "type": "channel"}, "text": "hi"}}`, 1000+i*10+j, j)
+				hook.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("POST", "/", strings.NewReader(update)))
+			}
+		}(i)
+	}
+	wg.Wait()
+	for deadline := time.Now().Add(5 * time.Second); atomic.LoadInt64(&polled) < 100; time.Sleep(10 * time.Millisecond) {
+		if time.Now().After(deadline) {
+			t.Fatalf("timed out waiting for the polled updates, got %d", atomic.LoadInt64(&polled))
+		}
+	}
+	h := &telegramtest.Harness{Connection: c}
+	if d := h.Inject(`{"update_id": 2000, "edited_message": {"message_id": 1, "chat": {"id": 1}, "text": "x"}}`); d.Err != nil {
+		t.Fatal(d.Err)
+	}
+	if n := atomic.LoadInt64(&late); n != 1 {
+		t.Errorf("expected the first late handler to handle the edit, got %d calls", n)
+	}
+	if n := atomic.LoadInt64(&hooked); n != 80 {
+		t.Errorf("expected 80 handled webhook updates, got %d", n)
+	}
+}
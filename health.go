@@ -0,0 +1,54 @@
+package telegram
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+)
+
+// maxPollFailures is the number of consecutive transient getUpdates failures
+// after which the circuit is considered open and Healthy reports an error.
+const maxPollFailures = 3
+
+func (c *Connection) Healthy() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.user.ID == 0 {
+		return fmt.Errorf("not started: getMe has not succeeded")
+	}
+	if c.pollFailures >= maxPollFailures {
+		return fmt.Errorf("circuit open: last %d polls failed", c.pollFailures)
+	}
+	if c.webhook {
+		// Webhooks are only called when there are updates, so there's no
+		// activity to expect.
+		return nil
+	}
+	maxAge := 3 * c.timeout()
+	if maxAge < 30*time.Second {
+		maxAge = 30 * time.Second
+	}
+	if age := time.Since(c.lastPoll); age > maxAge {
+		return fmt.Errorf("last successful poll was %s ago", age.Round(time.Second))
+	}
+	return nil
+}
+
+func (c *Connection) HealthHandler() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if err := c.Healthy(); err != nil {
+			http.Error(w, err.Error(), http.StatusServiceUnavailable)
+			return
+		}
+		fmt.Fprintln(w, "ok")
+	}
+}
+
+func (c *Connection) recordPollFailure(err error) {
+	if err == nil || !IsRetryable(err) {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.pollFailures++
+}
@@ -0,0 +1,78 @@
+package telegram_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/niklasfasching/telegram"
+	"github.com/niklasfasching/telegram/telegramtest"
+)
+
+func TestHealthyCircuit(t *testing.T) {
+	s := telegramtest.NewServer()
+	defer s.Close()
+	c := s.Connection()
+	c.OnError = func(error) {}
+	// Each step waits for a long poll to end, and backoffs start at a second,
+	// which would add up to several seconds of getUpdates retries until the
+	// circuit opens.
+	c.Timeout = 50 * time.Millisecond
+	telegram.SetClock(c, time.Now, func(ctx context.Context, _ time.Duration) error {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(time.Millisecond):
+			return nil
+		}
+	})
+	if err := c.Healthy(); err == nil {
+		t.Fatal("expected unhealthy before Start")
+	}
+	go c.Start()
+	defer c.StopAndWait()
+	waitHealthy(t, c.Healthy, true)
+	s.RespondError("getUpdates", 502, "Bad Gateway")
+	waitHealthy(t, c.Healthy, false)
+	if err := c.Healthy(); !strings.Contains(err.Error(), "circuit open") {
+		t.Fatalf("expected open circuit, got %v", err)
+	}
+	w := httptest.NewRecorder()
+	c.HealthHandler()(w, httptest.NewRequest("GET", "/healthz", nil))
+	if w.Code != http.StatusServiceUnavailable {
+		t.Fatalf("expected 503, got %d", w.Code)
+	}
+	s.Reset("getUpdates")
+	waitHealthy(t, c.Healthy, true)
+}
+
+func waitHealthy(t *testing.T, healthy func() error, want bool) {
+	t.Helper()
+	for deadline := time.Now().Add(20 * time.Second); ; time.Sleep(10 * time.Millisecond) {
+		if err := healthy(); (err == nil) == want {
+			return
+		} else if time.Now().After(deadline) {
+			t.Fatalf("timed out waiting for healthy == %v: %v", want, err)
+		}
+	}
+}
+
+func TestHealthyWebhook(t *testing.T) {
+	s := telegramtest.NewServer()
+	defer s.Close()
+	c := s.Connection()
+	c.WebhookURL = "https://example.com/hook"
+	errc := make(chan error, 1)
+	go func() { errc <- c.StartWebhook("127.0.0.1:0", "/hook") }()
+	if _, err := s.WaitCall("setWebhook", 0, time.Second); err != nil {
+		t.Fatal(err)
+	}
+	waitHealthy(t, c.Healthy, true)
+	c.StopAndWait()
+	if err := <-errc; err != nil {
+		t.Fatal(err)
+	}
+}
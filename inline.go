@@ -0,0 +1,175 @@
+package telegram
+
+import (
+	"encoding/json"
+)
+
+type InlineQueryResult interface {
+	inlineQueryResultType() string
+}
+
+type InlineQueryResultCachedPhoto struct {
+	ID          string `json:"id"`
+	PhotoFileID string `json:"photo_file_id"`
+	Title       string `json:"title,omitempty"`
+	Description string `json:"description,omitempty"`
+	Caption     string `json:"caption,omitempty"`
+	ParseMode   string `json:"parse_mode,omitempty"`
+}
+
+type InlineQueryResultCachedGif struct {
+	ID        string `json:"id"`
+	GifFileID string `json:"gif_file_id"`
+	Title     string `json:"title,omitempty"`
+	Caption   string `json:"caption,omitempty"`
+	ParseMode string `json:"parse_mode,omitempty"`
+}
+
+type InlineQueryResultCachedMpeg4Gif struct {
+	ID          string `json:"id"`
+	Mpeg4FileID string `json:"mpeg4_file_id"`
+	Title       string `json:"title,omitempty"`
+	Caption     string `json:"caption,omitempty"`
+	ParseMode   string `json:"parse_mode,omitempty"`
+}
+
+type InlineQueryResultCachedSticker struct {
+	ID            string `json:"id"`
+	StickerFileID string `json:"sticker_file_id"`
+}
+
+type InlineQueryResultCachedDocument struct {
+	ID             string `json:"id"`
+	Title          string `json:"title"`
+	DocumentFileID string `json:"document_file_id"`
+	Description    string `json:"description,omitempty"`
+	Caption        string `json:"caption,omitempty"`
+	ParseMode      string `json:"parse_mode,omitempty"`
+}
+
+type InlineQueryResultCachedVideo struct {
+	ID          string `json:"id"`
+	VideoFileID string `json:"video_file_id"`
+	Title       string `json:"title"`
+	Description string `json:"description,omitempty"`
+	Caption     string `json:"caption,omitempty"`
+	ParseMode   string `json:"parse_mode,omitempty"`
+}
+
+type InlineQueryResultCachedVoice struct {
+	ID          string `json:"id"`
+	VoiceFileID string `json:"voice_file_id"`
+	Title       string `json:"title"`
+	Caption     string `json:"caption,omitempty"`
+	ParseMode   string `json:"parse_mode,omitempty"`
+}
+
+type InlineQueryResultCachedAudio struct {
+	ID          string `json:"id"`
+	AudioFileID string `json:"audio_file_id"`
+	Caption     string `json:"caption,omitempty"`
+	ParseMode   string `json:"parse_mode,omitempty"`
+}
+
+type InputTextMessageContent struct {
+	MessageText string          `json:"message_text"`
+	ParseMode   string          `json:"parse_mode,omitempty"`
+	Entities    []MessageEntity `json:"entities,omitempty"`
+}
+
+type InlineQueryResultArticle struct {
+	ID                  string                  `json:"id"`
+	Title               string                  `json:"title"`
+	InputMessageContent InputTextMessageContent `json:"input_message_content"`
+	ReplyMarkup         *InlineKeyboardMarkup   `json:"reply_markup,omitempty"`
+	URL                 string                  `json:"url,omitempty"`
+	Description         string                  `json:"description,omitempty"`
+	ThumbnailURL        string                  `json:"thumbnail_url,omitempty"`
+}
+
+type InlineQueryResultPhoto struct {
+	ID           string                `json:"id"`
+	PhotoURL     string                `json:"photo_url"`
+	ThumbnailURL string                `json:"thumbnail_url"`
+	Title        string                `json:"title,omitempty"`
+	Description  string                `json:"description,omitempty"`
+	Caption      string                `json:"caption,omitempty"`
+	ParseMode    string                `json:"parse_mode,omitempty"`
+	ReplyMarkup  *InlineKeyboardMarkup `json:"reply_markup,omitempty"`
+}
+
+type InlineQueryResultDocument struct {
+	ID           string                `json:"id"`
+	Title        string                `json:"title"`
+	DocumentURL  string                `json:"document_url"`
+	MimeType     string                `json:"mime_type"`
+	Description  string                `json:"description,omitempty"`
+	Caption      string                `json:"caption,omitempty"`
+	ParseMode    string                `json:"parse_mode,omitempty"`
+	ThumbnailURL string                `json:"thumbnail_url,omitempty"`
+	ReplyMarkup  *InlineKeyboardMarkup `json:"reply_markup,omitempty"`
+}
+
+// ArticleResult returns a result that sends text when chosen.
+func ArticleResult(id, title, text string) InlineQueryResultArticle {
+	return InlineQueryResultArticle{ID: id, Title: title, InputMessageContent: InputTextMessageContent{MessageText: text}}
+}
+
+func PhotoResult(id, photoURL, thumbnailURL string) InlineQueryResultPhoto {
+	return InlineQueryResultPhoto{ID: id, PhotoURL: photoURL, ThumbnailURL: thumbnailURL}
+}
+
+// DocumentResult returns a result for a document at url; only PDF and ZIP files
+// (application/pdf, application/zip) can be sent by URL.
+func DocumentResult(id, title, url, mimeType string) InlineQueryResultDocument {
+	return InlineQueryResultDocument{ID: id, Title: title, DocumentURL: url, MimeType: mimeType}
+}
+
+func (InlineQueryResultArticle) inlineQueryResultType() string  { return "article" }
+func (InlineQueryResultPhoto) inlineQueryResultType() string    { return "photo" }
+func (InlineQueryResultDocument) inlineQueryResultType() string { return "document" }
+
+func (InlineQueryResultCachedPhoto) inlineQueryResultType() string    { return "photo" }
+func (InlineQueryResultCachedGif) inlineQueryResultType() string      { return "gif" }
+func (InlineQueryResultCachedMpeg4Gif) inlineQueryResultType() string { return "mpeg4_gif" }
+func (InlineQueryResultCachedSticker) inlineQueryResultType() string  { return "sticker" }
+func (InlineQueryResultCachedDocument) inlineQueryResultType() string { return "document" }
+func (InlineQueryResultCachedVideo) inlineQueryResultType() string    { return "video" }
+func (InlineQueryResultCachedVoice) inlineQueryResultType() string    { return "voice" }
+func (InlineQueryResultCachedAudio) inlineQueryResultType() string    { return "audio" }
+
+func WithNextOffset(offset string) Option {
+	return func(p map[string]interface{}) { p["next_offset"] = offset }
+}
+
+func WithIsPersonal(isPersonal bool) Option {
+	return func(p map[string]interface{}) { p["is_personal"] = isPersonal }
+}
+
+func (c *Connection) AnswerInlineQuery(inlineQueryID string, results []InlineQueryResult, opts ...Option) error {
+	rs := make([]json.RawMessage, len(results))
+	for i, r := range results {
+		bs, err := marshalWithField(r, "type", r.inlineQueryResultType())
+		if err != nil {
+			return err
+		}
+		rs[i] = bs
+	}
+	return c.Call("answerInlineQuery", applyOptions(map[string]interface{}{
+		"inline_query_id": inlineQueryID,
+		"results":         rs,
+	}, opts), nil)
+}
+
+func marshalWithField(v interface{}, field, value string) (json.RawMessage, error) {
+	bs, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	m := map[string]json.RawMessage{}
+	if err := json.Unmarshal(bs, &m); err != nil {
+		return nil, err
+	}
+	m[field], _ = json.Marshal(value)
+	return json.Marshal(m)
+}
@@ -0,0 +1,63 @@
+package telegram_test
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strconv"
+	"testing"
+
+	"github.com/niklasfasching/telegram"
+	"github.com/niklasfasching/telegram/telegramtest"
+)
+
+func TestAnswerInlineQueryCachedPhoto(t *testing.T) {
+	s := telegramtest.NewServer()
+	defer s.Close()
+	s.Respond("answerInlineQuery", true)
+	results := []telegram.InlineQueryResult{
+		telegram.InlineQueryResultCachedPhoto{ID: "1", PhotoFileID: "AgAD", Caption: "cat"},
+		telegram.InlineQueryResultCachedSticker{ID: "2", StickerFileID: "CAAD"},
+	}
+	if err := s.Connection().AnswerInlineQuery("q", results); err != nil {
+		t.Fatal(err)
+	}
+	got := []map[string]interface{}{}
+	if err := json.Unmarshal([]byte(s.Calls("answerInlineQuery")[0].Params["results"]), &got); err != nil {
+		t.Fatal(err)
+	}
+	want := []map[string]interface{}{
+		{"type": "photo", "id": "1", "photo_file_id": "AgAD", "caption": "cat"},
+		{"type": "sticker", "id": "2", "sticker_file_id": "CAAD"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got results %v, want %v", got, want)
+	}
+}
+
+func TestInlineQueryPaging(t *testing.T) {
+	s := telegramtest.NewServer()
+	defer s.Close()
+	c := s.Connection()
+	c.Handle("inline_query", func(q telegram.InlineQuery) error {
+		page, _ := strconv.Atoi(q.Offset)
+		results := []telegram.InlineQueryResult{telegram.InlineQueryResultCachedPhoto{ID: strconv.Itoa(page), PhotoFileID: "a"}}
+		return c.AnswerInlineQuery(q.ID, results, telegram.WithNextOffset(strconv.Itoa(page+1)), telegram.WithIsPersonal(true))
+	})
+	h := &telegramtest.Harness{Connection: c}
+	offset := ""
+	for i := 0; i < 3; i++ {
+		update := fmt.Sprintf(`{"update_id": %d, "inline_query": {"id": "q%d", "from": {"id": 1}, "query": "cats", "offset": %q}}`, i+1, i, offset)
+		if d := h.Inject(update); d.Err != nil {
+			t.Fatal(d.Err)
+		}
+		call := s.Calls("answerInlineQuery")[i]
+		if call.Params["is_personal"] != "true" || call.Params["inline_query_id"] != fmt.Sprintf("q%d", i) {
+			t.Errorf("unexpected answer %v", call.Params)
+		}
+		offset = call.Params["next_offset"]
+	}
+	if offset != "3" {
+		t.Errorf("expected next_offset to round-trip, got %q after 3 pages", offset)
+	}
+}
@@ -0,0 +1,222 @@
+// Command gen writes typed Connection methods for the Bot API methods in a
+// schema (see api.json, which follows the layout of the community-maintained
+// JSON spec of the Bot API) that have no hand-written wrapper yet.
+//
+// Required fields become arguments, optional ones are left to opts. Methods
+// with required fields or results whose types it can't map are skipped, as
+// are methods the package already defines, so hand-written wrappers always
+// take precedence.
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"go/ast"
+	"go/format"
+	"go/parser"
+	"go/token"
+	"io/ioutil"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+type spec struct {
+	Version string            `json:"version"`
+	Methods map[string]method `json:"methods"`
+}
+
+type method struct {
+	Name        string   `json:"name"`
+	Description []string `json:"description"`
+	Returns     []string `json:"returns"`
+	Fields      []field  `json:"fields"`
+}
+
+type field struct {
+	Name     string   `json:"name"`
+	Types    []string `json:"types"`
+	Required bool     `json:"required"`
+}
+
+func main() {
+	specPath := flag.String("spec", "internal/gen/api.json", "Bot API schema")
+	out := flag.String("out", "methods_gen.go", "output file, next to the package it extends")
+	flag.Parse()
+	bs, err := ioutil.ReadFile(*specPath)
+	if err != nil {
+		log.Fatal(err)
+	}
+	s := spec{}
+	if err := json.Unmarshal(bs, &s); err != nil {
+		log.Fatalf("%s: %s", *specPath, err)
+	}
+	methods, types, err := declared(filepath.Dir(*out), filepath.Base(*out))
+	if err != nil {
+		log.Fatal(err)
+	}
+	src, err := generate(s, methods, types)
+	if err != nil {
+		log.Fatal(err)
+	}
+	if err := ioutil.WriteFile(*out, src, 0644); err != nil {
+		log.Fatal(err)
+	}
+}
+
+// declared returns the Connection methods and the types of the package in dir,
+// ignoring tests and the previous output.
+func declared(dir, out string) (map[string]bool, map[string]bool, error) {
+	filter := func(fi os.FileInfo) bool { return fi.Name() != out && !strings.HasSuffix(fi.Name(), "_test.go") }
+	pkgs, err := parser.ParseDir(token.NewFileSet(), dir, filter, 0)
+	if err != nil {
+		return nil, nil, err
+	}
+	methods, types := map[string]bool{}, map[string]bool{}
+	for _, pkg := range pkgs {
+		for _, f := range pkg.Files {
+			for _, decl := range f.Decls {
+				switch d := decl.(type) {
+				case *ast.FuncDecl:
+					if d.Recv != nil && len(d.Recv.List) == 1 {
+						if star, ok := d.Recv.List[0].Type.(*ast.StarExpr); ok {
+							if ident, ok := star.X.(*ast.Ident); ok && ident.Name == "Connection" {
+								methods[d.Name.Name] = true
+							}
+						}
+					}
+				case *ast.GenDecl:
+					for _, s := range d.Specs {
+						if t, ok := s.(*ast.TypeSpec); ok {
+							types[t.Name.Name] = true
+						}
+					}
+				}
+			}
+		}
+	}
+	return methods, types, nil
+}
+
+func generate(s spec, methods, types map[string]bool) ([]byte, error) {
+	names := []string{}
+	for name := range s.Methods {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	b := &bytes.Buffer{}
+	fmt.Fprintf(b, "// Code generated by internal/gen from %s; DO NOT EDIT.\n\npackage telegram\n", s.Version)
+	for _, name := range names {
+		m := s.Methods[name]
+		goName := strings.ToUpper(m.Name[:1]) + m.Name[1:]
+		if methods[goName] {
+			continue
+		}
+		if err := writeMethod(b, goName, m, types); err != nil {
+			log.Printf("skipping %s: %s", m.Name, err)
+		}
+	}
+	return format.Source(b.Bytes())
+}
+
+func writeMethod(b *bytes.Buffer, goName string, m method, types map[string]bool) error {
+	result, err := resultType(m.Returns, types)
+	if err != nil {
+		return err
+	}
+	args, params, hasOptional := []string{}, []string{}, false
+	for _, f := range m.Fields {
+		if !f.Required {
+			hasOptional = true
+			continue
+		}
+		t, err := fieldType(f)
+		if err != nil {
+			return err
+		}
+		arg := argName(f.Name)
+		args = append(args, arg+" "+t)
+		params = append(params, fmt.Sprintf("%q: %s", f.Name, arg))
+	}
+	data := "nil"
+	if len(params) != 0 {
+		data = "map[string]interface{}{" + strings.Join(params, ", ") + "}"
+	}
+	if hasOptional {
+		args = append(args, "opts ...Option")
+		if len(params) == 0 {
+			data = "map[string]interface{}{}"
+		}
+		data = "applyOptions(" + data + ", opts)"
+	}
+	fmt.Fprintf(b, "\n// %s calls %s. %s\n", goName, m.Name, strings.Join(m.Description, " "))
+	if result == "" {
+		fmt.Fprintf(b, "func (c *Connection) %s(%s) error {\n\treturn c.Call(%q, %s, nil)\n}\n", goName, strings.Join(args, ", "), m.Name, data)
+		return nil
+	}
+	fmt.Fprintf(b, "func (c *Connection) %s(%s) (%s, error) {\n", goName, strings.Join(args, ", "), result)
+	if result == "string" {
+		fmt.Fprintf(b, "\tr := \"\"\n")
+	} else {
+		fmt.Fprintf(b, "\tr := %s{}\n", result)
+	}
+	fmt.Fprintf(b, "\terr := c.Call(%q, %s, &r)\n\treturn r, err\n}\n", m.Name, data)
+	return nil
+}
+
+// resultType maps the result of a method: "" for methods that only return
+// True, which Call reports as a nil error.
+func resultType(returns []string, types map[string]bool) (string, error) {
+	switch {
+	case len(returns) != 1:
+		return "", fmt.Errorf("unsupported result %v", returns)
+	case returns[0] == "Boolean":
+		return "", nil
+	case returns[0] == "String":
+		return "string", nil
+	case types[returns[0]]:
+		return returns[0], nil
+	}
+	return "", fmt.Errorf("no type %s", returns[0])
+}
+
+// fieldType maps a field to the Go type the hand-written wrappers use for it:
+// ids of chats and users are int64, chat ids given as @username aren't
+// supported.
+func fieldType(f field) (string, error) {
+	switch t := strings.Join(f.Types, " or "); {
+	case t == "Integer or String" && f.Name == "chat_id":
+		return "int64", nil
+	case t == "Integer" && (strings.HasSuffix(f.Name, "chat_id") || f.Name == "user_id"):
+		return "int64", nil
+	case t == "Integer":
+		return "int", nil
+	case t == "String":
+		return "string", nil
+	case t == "Boolean":
+		return "bool", nil
+	case t == "Float":
+		return "float64", nil
+	default:
+		return "", fmt.Errorf("unsupported type %s of %s", t, f.Name)
+	}
+}
+
+// argName turns a field name into the argument name, e.g. sender_chat_id into
+// senderChatID.
+func argName(name string) string {
+	parts := strings.Split(name, "_")
+	for i, p := range parts {
+		switch {
+		case p == "id":
+			parts[i] = "ID"
+		case i != 0:
+			parts[i] = strings.ToUpper(p[:1]) + p[1:]
+		}
+	}
+	return strings.Join(parts, "")
+}
@@ -0,0 +1,131 @@
+package telegram
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+type ReplyKeyboardMarkup struct {
+	Keyboard              [][]KeyboardButton `json:"keyboard"`
+	IsPersistent          bool               `json:"is_persistent,omitempty"`
+	ResizeKeyboard        bool               `json:"resize_keyboard,omitempty"`
+	OneTimeKeyboard       bool               `json:"one_time_keyboard,omitempty"`
+	InputFieldPlaceholder string             `json:"input_field_placeholder,omitempty"`
+	Selective             bool               `json:"selective,omitempty"`
+}
+
+type InlineKeyboardMarkup struct {
+	InlineKeyboard [][]InlineKeyboardButton `json:"inline_keyboard"`
+}
+
+type InlineKeyboardButton struct {
+	Text                         string        `json:"text"`
+	URL                          string        `json:"url,omitempty"`
+	CallbackData                 string        `json:"callback_data,omitempty"`
+	SwitchInlineQuery            string        `json:"switch_inline_query,omitempty"`
+	SwitchInlineQueryCurrentChat string        `json:"switch_inline_query_current_chat,omitempty"`
+	CallbackGame                 *CallbackGame `json:"callback_game,omitempty"`
+	LoginURL                     *LoginURL     `json:"login_url,omitempty"`
+	Pay                          bool          `json:"pay,omitempty"`
+	WebApp                       *WebAppInfo   `json:"web_app,omitempty"`
+}
+
+type CallbackGame struct{}
+
+type LoginURL struct {
+	URL                string `json:"url"`
+	ForwardText        string `json:"forward_text,omitempty"`
+	BotUsername        string `json:"bot_username,omitempty"`
+	RequestWriteAccess bool   `json:"request_write_access,omitempty"`
+}
+
+func NewInlineKeyboard() *InlineKeyboardMarkup {
+	return &InlineKeyboardMarkup{InlineKeyboard: [][]InlineKeyboardButton{}}
+}
+
+func (m *InlineKeyboardMarkup) Row(buttons ...InlineKeyboardButton) *InlineKeyboardMarkup {
+	m.InlineKeyboard = append(m.InlineKeyboard, buttons)
+	return m
+}
+
+func Button(text, callbackData string) InlineKeyboardButton {
+	return InlineKeyboardButton{Text: text, CallbackData: callbackData}
+}
+
+func URLButton(text, url string) InlineKeyboardButton {
+	return InlineKeyboardButton{Text: text, URL: url}
+}
+
+func NewReplyKeyboard() *ReplyKeyboardMarkup {
+	return &ReplyKeyboardMarkup{Keyboard: [][]KeyboardButton{}, ResizeKeyboard: true}
+}
+
+func (m *ReplyKeyboardMarkup) Row(texts ...string) *ReplyKeyboardMarkup {
+	row := make([]KeyboardButton, len(texts))
+	for i, text := range texts {
+		row[i] = KeyboardButton{Text: text}
+	}
+	m.Keyboard = append(m.Keyboard, row)
+	return m
+}
+
+func GameButton(text string) InlineKeyboardButton {
+	return InlineKeyboardButton{Text: text, CallbackGame: &CallbackGame{}}
+}
+
+func LoginButton(text string, loginURL LoginURL) InlineKeyboardButton {
+	return InlineKeyboardButton{Text: text, LoginURL: &loginURL}
+}
+
+func (b InlineKeyboardButton) MarshalJSON() ([]byte, error) {
+	actions := 0
+	for _, set := range []bool{b.URL != "", b.CallbackData != "", b.SwitchInlineQuery != "",
+		b.SwitchInlineQueryCurrentChat != "", b.CallbackGame != nil, b.LoginURL != nil, b.Pay, b.WebApp != nil} {
+		if set {
+			actions++
+		}
+	}
+	if actions != 1 {
+		return nil, fmt.Errorf("inline keyboard button %q must have exactly one action, has %d", b.Text, actions)
+	}
+	type button InlineKeyboardButton
+	return json.Marshal(button(b))
+}
+
+type ReplyKeyboardRemove struct {
+	RemoveKeyboard bool `json:"remove_keyboard"`
+	Selective      bool `json:"selective,omitempty"`
+}
+
+type ForceReply struct {
+	ForceReply            bool   `json:"force_reply"`
+	InputFieldPlaceholder string `json:"input_field_placeholder,omitempty"`
+	Selective             bool   `json:"selective,omitempty"`
+}
+
+func selective(markup interface{}) interface{} {
+	switch m := markup.(type) {
+	case ReplyKeyboardMarkup:
+		m.Selective = true
+		return m
+	case *ReplyKeyboardMarkup:
+		if m != nil {
+			return selective(*m)
+		}
+	case ReplyKeyboardRemove:
+		m.Selective = true
+		return m
+	case *ReplyKeyboardRemove:
+		if m != nil {
+			return selective(*m)
+		}
+	case ForceReply:
+		m.Selective = true
+		return m
+	case *ForceReply:
+		if m != nil {
+			return selective(*m)
+		}
+	}
+	return markup
+}
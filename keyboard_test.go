@@ -0,0 +1,67 @@
+package telegram_test
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/niklasfasching/telegram"
+	"github.com/niklasfasching/telegram/telegramtest"
+)
+
+func TestInlineKeyboardJSON(t *testing.T) {
+	markup := telegram.NewInlineKeyboard().
+		Row(telegram.Button("Yes", "vote:yes"), telegram.Button("No", "vote:no")).
+		Row(telegram.URLButton("Docs", "https://example.com"), telegram.GameButton("Play"))
+	bs, err := json.Marshal(markup)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `{"inline_keyboard":[[{"text":"Yes","callback_data":"vote:yes"},{"text":"No","callback_data":"vote:no"}],[{"text":"Docs","url":"https://example.com"},{"text":"Play","callback_game":{}}]]}`
+	if string(bs) != want {
+		t.Errorf("got %s, want %s", bs, want)
+	}
+	for _, b := range []telegram.InlineKeyboardButton{{Text: "none"}, {Text: "two", URL: "https://example.com", CallbackData: "x"}} {
+		if _, err := json.Marshal(b); err == nil {
+			t.Errorf("expected button %q to be rejected", b.Text)
+		}
+	}
+
+	s := telegramtest.NewServer()
+	defer s.Close()
+	if _, err := s.Connection().SendMessage(1, "vote", telegram.WithReplyMarkup(markup)); err != nil {
+		t.Fatal(err)
+	} else if got := s.Calls("sendMessage")[0].Params["reply_markup"]; got != want {
+		t.Errorf("got reply_markup %s, want %s", got, want)
+	}
+}
+
+func TestCallbackQueryJSON(t *testing.T) {
+	q := telegram.CallbackQuery{}
+	data := `{"id": "q", "from": {"id": 1, "first_name": "a"}, "message": {"message_id": 2, "chat": {"id": 3}}, "chat_instance": "c", "data": "vote:yes"}`
+	if err := json.Unmarshal([]byte(data), &q); err != nil {
+		t.Fatal(err)
+	} else if q.ID != "q" || q.From.ID != 1 || q.Message == nil || q.Message.Chat.ID != 3 || q.Data != "vote:yes" {
+		t.Errorf("unexpected callback query %#v", q)
+	}
+}
+
+func TestLoginButtonJSON(t *testing.T) {
+	login := telegram.LoginButton("Log in", telegram.LoginURL{URL: "https://example.com/auth", BotUsername: "test_bot", RequestWriteAccess: true})
+	bs, err := json.Marshal(login)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := `{"text":"Log in","login_url":{"url":"https://example.com/auth","bot_username":"test_bot","request_write_access":true}}`; string(bs) != want {
+		t.Errorf("got %s, want %s", bs, want)
+	}
+	login.CallbackData = "login"
+	s := telegramtest.NewServer()
+	defer s.Close()
+	markup := telegram.NewInlineKeyboard().Row(login)
+	if _, err := s.Connection().SendMessage(1, "hi", telegram.WithReplyMarkup(markup)); err == nil || !strings.Contains(err.Error(), "has 2") {
+		t.Errorf("expected the button with two actions to be rejected, got %v", err)
+	} else if n := len(s.SentMessages()); n != 0 {
+		t.Errorf("expected nothing to be sent, got %d messages", n)
+	}
+}
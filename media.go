@@ -0,0 +1,187 @@
+package telegram
+
+import (
+	"fmt"
+	"io"
+	"strconv"
+)
+
+// InputFile is either a file_id / URL string or an io.Reader to upload.
+type InputFile interface{}
+
+type InputMedia interface {
+	inputMediaType() string
+	setCaption(caption string, mode ParseMode)
+}
+
+type InputMediaPhoto struct {
+	Media      InputFile `json:"media"`
+	Caption    string    `json:"caption,omitempty"`
+	ParseMode  string    `json:"parse_mode,omitempty"`
+	HasSpoiler bool      `json:"has_spoiler,omitempty"`
+}
+
+type InputMediaVideo struct {
+	Media             InputFile `json:"media"`
+	Caption           string    `json:"caption,omitempty"`
+	ParseMode         string    `json:"parse_mode,omitempty"`
+	Width             int       `json:"width,omitempty"`
+	Height            int       `json:"height,omitempty"`
+	Duration          int       `json:"duration,omitempty"`
+	SupportsStreaming bool      `json:"supports_streaming,omitempty"`
+	HasSpoiler        bool      `json:"has_spoiler,omitempty"`
+	Cover             InputFile `json:"cover,omitempty"`
+	StartTimestamp    int       `json:"start_timestamp,omitempty"`
+}
+
+type InputMediaDocument struct {
+	Media     InputFile `json:"media"`
+	Caption   string    `json:"caption,omitempty"`
+	ParseMode string    `json:"parse_mode,omitempty"`
+}
+
+type InputMediaAudio struct {
+	Media     InputFile `json:"media"`
+	Caption   string    `json:"caption,omitempty"`
+	ParseMode string    `json:"parse_mode,omitempty"`
+	Duration  int       `json:"duration,omitempty"`
+	Performer string    `json:"performer,omitempty"`
+	Title     string    `json:"title,omitempty"`
+}
+
+func (*InputMediaPhoto) inputMediaType() string    { return "photo" }
+func (*InputMediaVideo) inputMediaType() string    { return "video" }
+func (*InputMediaDocument) inputMediaType() string { return "document" }
+func (*InputMediaAudio) inputMediaType() string    { return "audio" }
+
+func (m *InputMediaPhoto) setCaption(caption string, mode ParseMode) {
+	m.Caption, m.ParseMode = caption, string(mode)
+}
+
+func (m *InputMediaVideo) setCaption(caption string, mode ParseMode) {
+	m.Caption, m.ParseMode = caption, string(mode)
+}
+
+func (m *InputMediaDocument) setCaption(caption string, mode ParseMode) {
+	m.Caption, m.ParseMode = caption, string(mode)
+}
+
+func (m *InputMediaAudio) setCaption(caption string, mode ParseMode) {
+	m.Caption, m.ParseMode = caption, string(mode)
+}
+
+func WithCover(cover InputFile) Option {
+	return func(p map[string]interface{}) { p["cover"] = attach(p, "cover", cover) }
+}
+
+func WithStartTimestamp(seconds int) Option {
+	return func(p map[string]interface{}) { p["start_timestamp"] = seconds }
+}
+
+func (c *Connection) SendPhoto(chatID int64, photo InputFile, opts ...Option) (Message, error) {
+	m := Message{}
+	err := c.Call("sendPhoto", applyOptions(map[string]interface{}{"chat_id": chatID, "photo": photo}, opts), &m)
+	return m, err
+}
+
+func (c *Connection) SendAudio(chatID int64, audio InputFile, opts ...Option) (Message, error) {
+	m := Message{}
+	err := c.Call("sendAudio", applyOptions(map[string]interface{}{"chat_id": chatID, "audio": audio}, opts), &m)
+	return m, err
+}
+
+func WithDisableContentTypeDetection(disable bool) Option {
+	return func(p map[string]interface{}) { p["disable_content_type_detection"] = disable }
+}
+
+func (c *Connection) SendDocument(chatID int64, document InputFile, opts ...Option) (Message, error) {
+	m := Message{}
+	err := c.Call("sendDocument", applyOptions(map[string]interface{}{"chat_id": chatID, "document": document}, opts), &m)
+	return m, err
+}
+
+func (c *Connection) SendVideo(chatID int64, video InputFile, opts ...Option) (Message, error) {
+	m := Message{}
+	err := c.Call("sendVideo", applyOptions(map[string]interface{}{"chat_id": chatID, "video": video}, opts), &m)
+	return m, err
+}
+
+// SendMediaGroup sends 2-10 media as an album. Media and video covers that are
+// io.Readers or LocalFiles are uploaded as attachments.
+func (c *Connection) SendMediaGroup(chatID int64, media []InputMedia, opts ...Option) ([]Message, error) {
+	ms := []Message{}
+	if len(media) < 2 || len(media) > 10 {
+		return ms, fmt.Errorf("sendMediaGroup: media must contain 2-10 items, not %d", len(media))
+	}
+	params, items := applyOptions(map[string]interface{}{"chat_id": chatID}, opts), []map[string]interface{}{}
+	for i, m := range media {
+		item, err := mediaItem(params, strconv.Itoa(i), m)
+		if err != nil {
+			return ms, err
+		}
+		items = append(items, item)
+	}
+	params["media"] = items
+	err := c.Call("sendMediaGroup", params, &ms)
+	return ms, err
+}
+
+func (c *Connection) EditMessageMedia(chatID int64, messageID int, media InputMedia, opts ...Option) (Message, error) {
+	m, params := Message{}, applyOptions(map[string]interface{}{"chat_id": chatID, "message_id": messageID}, opts)
+	item, err := mediaItem(params, "0", media)
+	if err != nil {
+		return m, err
+	}
+	params["media"] = item
+	err = c.Call("editMessageMedia", params, &m)
+	return m, err
+}
+
+// mediaItem encodes m as an entry of a media param, with its uploads attached
+// to params.
+func mediaItem(params map[string]interface{}, name string, m InputMedia) (map[string]interface{}, error) {
+	item, err := toMap(m)
+	if err != nil {
+		return nil, err
+	}
+	item["type"] = m.inputMediaType()
+	item["media"] = attach(params, name, item["media"])
+	if cover, ok := item["cover"]; ok {
+		item["cover"] = attach(params, "cover_"+name, cover)
+	}
+	return item, nil
+}
+
+// mediaItems returns the entries of the media param of sendMediaGroup and
+// editMessageMedia, if params has one.
+func mediaItems(params map[string]interface{}) []map[string]interface{} {
+	switch v := params["media"].(type) {
+	case []map[string]interface{}:
+		return v
+	case map[string]interface{}:
+		return []map[string]interface{}{v}
+	}
+	return nil
+}
+
+// attach adds uploads to params as their own multipart part and returns the
+// attach:// reference Telegram expects in fields that can't carry a file directly.
+func attach(params map[string]interface{}, name string, f InputFile) interface{} {
+	r, ok := f.(io.Reader)
+	if !ok {
+		return f
+	}
+	name = "attach_" + name
+	params[name] = r
+	return "attach://" + name
+}
+
+// AlbumCaption sets the caption Telegram shows for the whole album. Clients only
+// display it as the album caption if it is set on the first item and no other
+// item carries a caption of its own.
+func AlbumCaption(media []InputMedia, caption string, mode ParseMode) []InputMedia {
+	if len(media) != 0 {
+		media[0].setCaption(caption, mode)
+	}
+	return media
+}
@@ -0,0 +1,157 @@
+package telegram_test
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/niklasfasching/telegram"
+	"github.com/niklasfasching/telegram/telegramtest"
+)
+
+func writeFiles(t *testing.T, contents ...string) []telegram.LocalFile {
+	dir, files := t.TempDir(), []telegram.LocalFile{}
+	for i, content := range contents {
+		path := filepath.Join(dir, string(rune('a'+i)))
+		if err := ioutil.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+		files = append(files, telegram.LocalFile(path))
+	}
+	return files
+}
+
+func decodeMedia(t *testing.T, param string, v interface{}) {
+	t.Helper()
+	if err := json.Unmarshal([]byte(param), v); err != nil {
+		t.Fatalf("invalid media %s: %s", param, err)
+	}
+}
+
+func TestMediaGroupLocalFiles(t *testing.T) {
+	s := telegramtest.NewServer()
+	defer s.Close()
+	s.Respond("sendMediaGroup", []telegram.Message{})
+	files := writeFiles(t, "photo", "video", "cover")
+	c := s.Connection()
+	media := []telegram.InputMedia{
+		&telegram.InputMediaPhoto{Media: files[0]},
+		&telegram.InputMediaVideo{Media: files[1], Cover: files[2]},
+	}
+	if _, err := c.SendMediaGroup(1, media); err != nil {
+		t.Fatal(err)
+	}
+	call, items := s.Calls("sendMediaGroup")[0], []map[string]string{}
+	decodeMedia(t, call.Params["media"], &items)
+	for i, want := range []map[string]string{{"media": "photo"}, {"media": "video", "cover": "cover"}} {
+		for k, content := range want {
+			name := items[i][k][len("attach://"):]
+			if got := string(call.Files[name]); got != content {
+				t.Errorf("item %d %s: got upload %q for %s, want %q", i, k, got, items[i][k], content)
+			}
+		}
+	}
+	if media[0].(*telegram.InputMediaPhoto).Media != files[0] {
+		t.Error("media was modified")
+	}
+
+	c.LocalMode = true
+	if _, err := c.SendMediaGroup(1, media); err != nil {
+		t.Fatal(err)
+	}
+	call, items = s.Calls("sendMediaGroup")[1], []map[string]string{}
+	decodeMedia(t, call.Params["media"], &items)
+	if len(call.Files) != 0 || items[0]["media"] != "file://"+string(files[0]) || items[1]["cover"] != "file://"+string(files[2]) {
+		t.Errorf("expected file:// paths in LocalMode, got %v and files %v", items, call.Files)
+	}
+}
+
+func TestEditMessageMediaLocalFile(t *testing.T) {
+	s := telegramtest.NewServer()
+	defer s.Close()
+	files := writeFiles(t, "document")
+	c := s.Connection()
+	if _, multipart, err := c.RenderRequest("editMessageMedia", map[string]interface{}{"media": map[string]interface{}{"media": files[0]}}); err != nil {
+		t.Fatal(err)
+	} else if !multipart {
+		t.Error("expected a nested LocalFile to make the request multipart")
+	}
+	s.Respond("editMessageMedia", telegram.Message{ID: 1})
+	if _, err := c.EditMessageMedia(1, 1, &telegram.InputMediaDocument{Media: files[0], Caption: "new"}); err != nil {
+		t.Fatal(err)
+	}
+	call, item := s.Calls("editMessageMedia")[0], map[string]string{}
+	decodeMedia(t, call.Params["media"], &item)
+	if item["type"] != "document" || item["caption"] != "new" || string(call.Files[item["media"][len("attach://"):]]) != "document" {
+		t.Errorf("unexpected media %v with files %v", item, call.Files)
+	}
+}
+
+func TestAlbumCaption(t *testing.T) {
+	s := telegramtest.NewServer()
+	defer s.Close()
+	s.Respond("sendMediaGroup", []telegram.Message{})
+	media := telegram.AlbumCaption([]telegram.InputMedia{
+		&telegram.InputMediaPhoto{Media: "a", HasSpoiler: true},
+		&telegram.InputMediaVideo{Media: "b", Caption: "<b>second</b>", ParseMode: "HTML"},
+	}, "*album*", telegram.ParseModeMarkdownV2)
+	if _, err := s.Connection().SendMediaGroup(1, media); err != nil {
+		t.Fatal(err)
+	}
+	items := []map[string]interface{}{}
+	decodeMedia(t, s.Calls("sendMediaGroup")[0].Params["media"], &items)
+	want := []map[string]interface{}{
+		{"type": "photo", "media": "a", "caption": "*album*", "parse_mode": "MarkdownV2", "has_spoiler": true},
+		{"type": "video", "media": "b", "caption": "<b>second</b>", "parse_mode": "HTML"},
+	}
+	if !reflect.DeepEqual(items, want) {
+		t.Errorf("got media %v, want %v", items, want)
+	}
+}
+
+func TestSendVideoCover(t *testing.T) {
+	s := telegramtest.NewServer()
+	defer s.Close()
+	s.Respond("sendVideo", telegram.Message{ID: 1})
+	c := s.Connection()
+	files := writeFiles(t, "video", "cover")
+	if _, err := c.SendVideo(1, files[0], telegram.WithCover(strings.NewReader("reader cover")), telegram.WithStartTimestamp(42)); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := c.SendVideo(1, "video_id", telegram.WithCover(files[1])); err != nil {
+		t.Fatal(err)
+	}
+	for i, want := range []string{"reader cover", "cover"} {
+		call := s.Calls("sendVideo")[i]
+		if ref := call.Params["cover"]; !strings.HasPrefix(ref, "attach://") || string(call.Files[ref[len("attach://"):]]) != want {
+			t.Errorf("call %d: got cover %q with files %v, want an attached %q", i, ref, call.Files, want)
+		}
+	}
+	if call := s.Calls("sendVideo")[0]; string(call.Files["video"]) != "video" || call.Params["start_timestamp"] != "42" {
+		t.Errorf("unexpected call %v", call)
+	}
+}
+
+func TestSendDocumentDisableContentTypeDetection(t *testing.T) {
+	s := telegramtest.NewServer()
+	defer s.Close()
+	s.Respond("sendDocument", telegram.Message{ID: 1})
+	c := s.Connection()
+	files := writeFiles(t, "png")
+	if _, err := c.SendDocument(1, files[0], telegram.WithDisableContentTypeDetection(true)); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := c.SendDocument(1, "file_id"); err != nil {
+		t.Fatal(err)
+	}
+	calls := s.Calls("sendDocument")
+	if call := calls[0]; call.Params["disable_content_type_detection"] != "true" || string(call.Files["document"]) != "png" {
+		t.Errorf("expected the flag to be forwarded with the upload, got %v", call)
+	}
+	if _, ok := calls[1].Params["disable_content_type_detection"]; ok {
+		t.Errorf("expected no flag by default, got %v", calls[1].Params)
+	}
+}
@@ -0,0 +1,43 @@
+package telegram
+
+// IsPresent reports whether the member is currently in the chat. Restricted
+// members may or may not be, see IsMember.
+func (m ChatMember) IsPresent() bool {
+	switch m.Status {
+	case "creator", "administrator", "member":
+		return true
+	case "restricted":
+		return m.IsMember
+	}
+	return false
+}
+
+func (m ChatMember) IsAdmin() bool { return m.Status == "creator" || m.Status == "administrator" }
+
+func (u ChatMemberUpdated) Joined() bool {
+	return !u.OldChatMember.IsPresent() && u.NewChatMember.IsPresent()
+}
+
+func (u ChatMemberUpdated) Left() bool {
+	return u.OldChatMember.IsPresent() && !u.NewChatMember.IsPresent()
+}
+
+func (u ChatMemberUpdated) WasKicked() bool {
+	return u.OldChatMember.Status != "kicked" && u.NewChatMember.Status == "kicked"
+}
+
+func (u ChatMemberUpdated) WasPromoted() bool {
+	return !u.OldChatMember.IsAdmin() && u.NewChatMember.IsAdmin()
+}
+
+func (u ChatMemberUpdated) WasDemoted() bool {
+	return u.OldChatMember.IsAdmin() && !u.NewChatMember.IsAdmin()
+}
+
+// BotAddedToGroup and BotRemoved are meant for my_chat_member updates, where
+// the member is the bot itself.
+func (u ChatMemberUpdated) BotAddedToGroup() bool {
+	return u.Joined() && (u.Chat.Type == "group" || u.Chat.Type == "supergroup")
+}
+
+func (u ChatMemberUpdated) BotRemoved() bool { return u.Left() }
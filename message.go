@@ -0,0 +1,207 @@
+package telegram
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+)
+
+const generalTopicID = 1
+
+func (m Message) Reply(c *Connection, text string, opts ...Option) (Message, error) {
+	if err := validateText("sendMessage", text); err != nil {
+		return Message{}, err
+	}
+	params := applyOptions(map[string]interface{}{
+		"chat_id":             m.Chat.ID,
+		"text":                text,
+		"reply_to_message_id": m.ID,
+	}, opts)
+	if m.IsTopicMessage && !IsGeneralTopic(m) {
+		params["message_thread_id"] = m.MessageThreadID
+	}
+	if markup, ok := params["reply_markup"]; ok && (m.Chat.Type == "group" || m.Chat.Type == "supergroup") {
+		params["reply_markup"] = selective(markup)
+	}
+	reply := Message{}
+	err := c.Call("sendMessage", params, &reply)
+	return reply, err
+}
+
+// Answer sends text to the chat (and topic) of m without quoting m.
+func (m Message) Answer(c *Connection, text string, opts ...Option) (Message, error) {
+	if m.IsTopicMessage && !IsGeneralTopic(m) {
+		return c.SendToTopic(m.Chat.ID, m.MessageThreadID, text, opts...)
+	}
+	return c.SendMessage(m.Chat.ID, text, opts...)
+}
+
+func (m Message) EditText(c *Connection, text string, opts ...Option) (Message, error) {
+	return c.EditMessageText(m.Chat.ID, m.ID, text, opts...)
+}
+
+func (m Message) Delete(c *Connection) error {
+	return c.DeleteMessage(m.Chat.ID, m.ID)
+}
+
+// ChatID identifies a chat by its numeric ID or, for channels and supergroups,
+// by its @username.
+type ChatID struct {
+	ID       int64
+	Username string
+}
+
+func (id ChatID) MarshalJSON() ([]byte, error) {
+	switch {
+	case id.ID != 0 && id.Username != "":
+		return nil, fmt.Errorf("chat id: both id %d and username %s are set", id.ID, id.Username)
+	case id.Username != "" && !strings.HasPrefix(id.Username, "@"):
+		return nil, fmt.Errorf("chat id: username %q must start with @", id.Username)
+	case id.Username != "":
+		return json.Marshal(id.Username)
+	case id.ID == 0:
+		return nil, fmt.Errorf("chat id: neither id nor username is set")
+	}
+	return json.Marshal(id.ID)
+}
+
+// ReplyParameters describes the message to reply to. Setting ChatID replies to
+// a message in another chat, which requires the bot to have access to it, e.g.
+// by being a member of that chat.
+type ReplyParameters struct {
+	MessageID                int             `json:"message_id"`
+	ChatID                   *ChatID         `json:"chat_id,omitempty"`
+	AllowSendingWithoutReply bool            `json:"allow_sending_without_reply,omitempty"`
+	Quote                    string          `json:"quote,omitempty"`
+	QuoteParseMode           string          `json:"quote_parse_mode,omitempty"`
+	QuoteEntities            []MessageEntity `json:"quote_entities,omitempty"`
+	QuotePosition            int             `json:"quote_position,omitempty"`
+}
+
+func WithReplyParameters(parameters ReplyParameters) Option {
+	return func(p map[string]interface{}) { p["reply_parameters"] = parameters }
+}
+
+func IsGeneralTopic(m Message) bool {
+	return m.Chat.IsForum && (!m.IsTopicMessage || m.MessageThreadID == generalTopicID)
+}
+
+// CanForward reports whether m may be forwarded or copied. Chat.HasProtectedContent
+// is only set on chats returned by GetChat, so pass such a chat in m.Chat to also
+// account for chat-wide protection.
+func CanForward(m Message) bool {
+	return !m.HasProtectedContent && !m.Chat.HasProtectedContent
+}
+
+func (c *Connection) SendToTopic(chatID int64, threadID int, text string, opts ...Option) (Message, error) {
+	if err := validateText("sendMessage", text); err != nil {
+		return Message{}, err
+	}
+	params := applyOptions(map[string]interface{}{"chat_id": chatID, "text": text}, opts)
+	if threadID > generalTopicID {
+		params["message_thread_id"] = threadID
+	}
+	m := Message{}
+	err := c.Call("sendMessage", params, &m)
+	return m, err
+}
+
+// DiscussionMessage waits for updates to be polled and must therefore not be
+// called synchronously from a handler - run it in its own goroutine.
+func (c *Connection) DiscussionMessage(ctx context.Context, post Message) (*Message, error) {
+	updates := c.Subscribe()
+	defer c.Unsubscribe(updates)
+	chat, err := c.GetChat(post.Chat.ID)
+	if err != nil {
+		return nil, err
+	} else if chat.LinkedChatID == 0 {
+		return nil, fmt.Errorf("channel %d has no linked discussion group", post.Chat.ID)
+	}
+	for {
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case u, ok := <-updates:
+			if !ok {
+				return nil, ErrStopped
+			}
+			if m := u.Message; m != nil && m.Chat.ID == chat.LinkedChatID && m.IsAutomaticForward &&
+				m.ForwardOrigin != nil && m.ForwardOrigin.Chat != nil &&
+				m.ForwardOrigin.Chat.ID == post.Chat.ID && m.ForwardOrigin.MessageID == post.ID {
+				return m, nil
+			}
+		}
+	}
+}
+
+func (m Message) IsEdited() bool { return m.EditDate != 0 }
+
+func (m Message) Time() time.Time { return unixTime(m.Date) }
+
+func (m Message) EditTime() time.Time { return unixTime(m.EditDate) }
+
+func (m Message) ForwardTime() time.Time {
+	if m.ForwardOrigin == nil {
+		return unixTime(m.ForwardDate)
+	}
+	return unixTime(m.ForwardOrigin.Date)
+}
+
+func unixTime(seconds int) time.Time {
+	if seconds == 0 {
+		return time.Time{}
+	}
+	return time.Unix(int64(seconds), 0).UTC()
+}
+
+// EntityText returns the part of m.Text covered by e.
+func (m Message) EntityText(e MessageEntity) string {
+	return UTF16Slice(m.Text, e.Offset, e.Offset+e.Length)
+}
+
+// URLs returns the links in m.Text, both written out and hidden behind text.
+func (m Message) URLs() []string {
+	urls := []string{}
+	for _, e := range m.Entities {
+		switch e.Type {
+		case "url":
+			urls = append(urls, m.EntityText(e))
+		case "text_link":
+			urls = append(urls, e.URL)
+		}
+	}
+	return urls
+}
+
+// BotCommands returns the commands in m.Text, e.g. "/start@examplebot".
+func (m Message) BotCommands() []string {
+	commands := []string{}
+	for _, e := range m.Entities {
+		if e.Type == "bot_command" {
+			commands = append(commands, m.EntityText(e))
+		}
+	}
+	return commands
+}
+
+type Mention struct {
+	Username string
+	User     *User
+}
+
+func (m Message) Mentions() []Mention {
+	mentions := []Mention{}
+	for _, e := range m.Entities {
+		switch e.Type {
+		case "mention":
+			mentions = append(mentions, Mention{Username: strings.TrimPrefix(m.EntityText(e), "@")})
+		case "text_mention":
+			if e.User != nil {
+				mentions = append(mentions, Mention{Username: e.User.Username, User: e.User})
+			}
+		}
+	}
+	return mentions
+}
@@ -0,0 +1,220 @@
+package telegram_test
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/niklasfasching/telegram"
+	"github.com/niklasfasching/telegram/telegramtest"
+)
+
+func TestReplySelective(t *testing.T) {
+	s := telegramtest.NewServer()
+	defer s.Close()
+	c := s.Connection()
+	markups := []interface{}{
+		telegram.ReplyKeyboardMarkup{Keyboard: [][]telegram.KeyboardButton{{{Text: "a"}}}},
+		&telegram.ReplyKeyboardMarkup{Keyboard: [][]telegram.KeyboardButton{{{Text: "a"}}}},
+		telegram.ReplyKeyboardRemove{RemoveKeyboard: true},
+		&telegram.ForceReply{ForceReply: true},
+		(*telegram.ForceReply)(nil),
+		telegram.InlineKeyboardMarkup{InlineKeyboard: [][]telegram.InlineKeyboardButton{{{Text: "a", CallbackData: "a"}}}},
+	}
+	tests := []struct {
+		chatType  string
+		selective []bool
+	}{
+		{"supergroup", []bool{true, true, true, true, false, false}},
+		{"private", []bool{false, false, false, false, false, false}},
+	}
+	for _, test := range tests {
+		m := telegram.Message{ID: 1, Chat: telegram.Chat{ID: 1, Type: test.chatType}}
+		for i, markup := range markups {
+			if _, err := m.Reply(c, "hi", telegram.WithReplyMarkup(markup)); err != nil {
+				t.Fatal(err)
+			}
+			calls := s.Calls("sendMessage")
+			sent := struct{ Selective bool }{}
+			json.Unmarshal([]byte(calls[len(calls)-1].Params["reply_markup"]), &sent)
+			if sent.Selective != test.selective[i] {
+				t.Errorf("%s: %T: got selective %v, want %v", test.chatType, markup, sent.Selective, test.selective[i])
+			}
+		}
+	}
+	if markup := markups[1].(*telegram.ReplyKeyboardMarkup); markup.Selective {
+		t.Error("markup was modified")
+	}
+}
+
+func TestGeneralTopic(t *testing.T) {
+	forum := telegram.Chat{ID: -100, Type: "supergroup", IsForum: true}
+	general := telegram.Message{ID: 1, Chat: forum}
+	generalThread := telegram.Message{ID: 2, Chat: forum, IsTopicMessage: true, MessageThreadID: 1}
+	topic := telegram.Message{ID: 3, Chat: forum, IsTopicMessage: true, MessageThreadID: 5}
+	group := telegram.Message{ID: 4, Chat: telegram.Chat{ID: -200, Type: "supergroup"}}
+	for _, test := range []struct {
+		m       telegram.Message
+		general bool
+	}{{general, true}, {generalThread, true}, {topic, false}, {group, false}} {
+		if got := telegram.IsGeneralTopic(test.m); got != test.general {
+			t.Errorf("message %d: IsGeneralTopic() = %v, want %v", test.m.ID, got, test.general)
+		}
+	}
+
+	s := telegramtest.NewServer()
+	defer s.Close()
+	c := s.Connection()
+	for _, m := range []telegram.Message{general, generalThread, topic} {
+		if _, err := m.Reply(c, "hi"); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := m.Answer(c, "hi"); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for i, want := range []string{"", "", "", "", "5", "5"} {
+		if got := s.Calls("sendMessage")[i].Params["message_thread_id"]; got != want {
+			t.Errorf("send %d: got message_thread_id %q, want %q", i, got, want)
+		}
+	}
+}
+
+func TestDiscussionMessage(t *testing.T) {
+	s := telegramtest.NewServer()
+	defer s.Close()
+	s.Respond("getChat", telegram.Chat{ID: -100, Type: "channel", LinkedChatID: -200})
+	c := s.Connection()
+	h := &telegramtest.Harness{Connection: c}
+	post := telegram.Message{ID: 7, Chat: telegram.Chat{ID: -100, Type: "channel"}}
+	found := make(chan *telegram.Message, 1)
+	go func() {
+		m, err := c.DiscussionMessage(context.Background(), post)
+		if err != nil {
+			t.Error(err)
+		}
+		found <- m
+	}()
+	if _, err := s.WaitCall("getChat", 0, time.Second); err != nil {
+		t.Fatal(err)
+	}
+	forward := `{"update_id": %d, "message": {"message_id": %d, "chat": {"id": -200, "type": "supergroup"}, "is_automatic_forward": true, "forward_origin": {"type": "channel", "chat": {"id": -100}, "message_id": %d}}}`
+	h.Inject(fmt.Sprintf(forward, 1, 11, 6))
+	h.Inject(fmt.Sprintf(forward, 2, 12, 7))
+	select {
+	case m := <-found:
+		if m == nil || m.ID != 12 {
+			t.Errorf("expected the copy of post 7, got %#v", m)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("timed out waiting for the discussion message")
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	if _, err := c.DiscussionMessage(ctx, post); err != context.DeadlineExceeded {
+		t.Errorf("expected the ctx error without a matching update, got %v", err)
+	}
+}
+
+func TestMessageTime(t *testing.T) {
+	m := telegram.Message{Date: 1700000000, EditDate: 1700000060, ForwardOrigin: &telegram.MessageOrigin{Date: 1600000000}}
+	if got, want := m.Time(), time.Date(2023, 11, 14, 22, 13, 20, 0, time.UTC); !got.Equal(want) || got.Location() != time.UTC {
+		t.Errorf("Time() = %s, want %s", got, want)
+	}
+	if got := m.EditTime(); got.Sub(m.Time()) != time.Minute {
+		t.Errorf("EditTime() = %s", got)
+	}
+	if got := m.ForwardTime(); got.Unix() != 1600000000 {
+		t.Errorf("ForwardTime() = %s", got)
+	}
+	if m := (telegram.Message{ForwardDate: 1500000000}); m.ForwardTime().Unix() != 1500000000 {
+		t.Errorf("expected the legacy forward_date, got %s", m.ForwardTime())
+	}
+	if m := (telegram.Message{}); !m.Time().IsZero() || !m.EditTime().IsZero() || !m.ForwardTime().IsZero() {
+		t.Errorf("expected zero times, got %s, %s and %s", m.Time(), m.EditTime(), m.ForwardTime())
+	}
+}
+
+func TestMentions(t *testing.T) {
+	m := telegram.Message{}
+	data := `{"message_id": 1, "chat": {"id": 1}, "text": "hi 👋 @ann and Bob\nx := 1", "entities": [
+		{"type": "mention", "offset": 6, "length": 4},
+		{"type": "text_mention", "offset": 15, "length": 3, "user": {"id": 42, "first_name": "Bob"}},
+		{"type": "pre", "offset": 19, "length": 6, "language": "go"}
+	]}`
+	if err := json.Unmarshal([]byte(data), &m); err != nil {
+		t.Fatal(err)
+	}
+	if e := m.Entities[1]; e.User == nil || e.User.ID != 42 || e.User.FirstName != "Bob" {
+		t.Errorf("expected the text_mention user to be decoded, got %#v", e)
+	}
+	if e := m.Entities[2]; e.Language != "go" {
+		t.Errorf("expected the pre language to be decoded, got %#v", e)
+	}
+	mentions := m.Mentions()
+	if len(mentions) != 2 || mentions[0].Username != "ann" || mentions[0].User != nil || mentions[1].User == nil || mentions[1].User.ID != 42 {
+		t.Errorf("unexpected mentions %#v", mentions)
+	}
+}
+
+func TestCanForward(t *testing.T) {
+	tests := []struct {
+		name, message string
+		chat          string
+		want          bool
+	}{
+		{"plain", `{"message_id": 1, "chat": {"id": 1}}`, `{"id": 1}`, true},
+		{"protected message", `{"message_id": 1, "chat": {"id": 1}, "has_protected_content": true}`, `{"id": 1}`, false},
+		{"protected chat", `{"message_id": 1, "chat": {"id": 1}}`, `{"id": 1, "has_protected_content": true}`, false},
+	}
+	for _, test := range tests {
+		m, chat := telegram.Message{}, telegram.Chat{}
+		if err := json.Unmarshal([]byte(test.message), &m); err != nil {
+			t.Fatal(err)
+		} else if err := json.Unmarshal([]byte(test.chat), &chat); err != nil {
+			t.Fatal(err)
+		}
+		if got := telegram.CanForward(m); got != !m.HasProtectedContent {
+			t.Errorf("%s: CanForward() = %v for the message alone", test.name, got)
+		}
+		m.Chat = chat
+		if got := telegram.CanForward(m); got != test.want {
+			t.Errorf("%s: CanForward() = %v, want %v", test.name, got, test.want)
+		}
+	}
+}
+
+func TestCrossChatReply(t *testing.T) {
+	s := telegramtest.NewServer()
+	defer s.Close()
+	c := s.Connection()
+	for _, test := range []struct {
+		chatID *telegram.ChatID
+		want   string
+	}{
+		{nil, `{"message_id":5}`},
+		{&telegram.ChatID{ID: -100}, `{"message_id":5,"chat_id":-100}`},
+		{&telegram.ChatID{Username: "@channel"}, `{"message_id":5,"chat_id":"@channel"}`},
+	} {
+		if _, err := c.SendMessage(1, "hi", telegram.WithReplyParameters(telegram.ReplyParameters{MessageID: 5, ChatID: test.chatID})); err != nil {
+			t.Fatal(err)
+		}
+		calls := s.Calls("sendMessage")
+		if got := calls[len(calls)-1].Params["reply_parameters"]; got != test.want {
+			t.Errorf("got reply_parameters %s, want %s", got, test.want)
+		}
+	}
+	for _, id := range []telegram.ChatID{{}, {Username: "channel"}, {ID: 1, Username: "@channel"}} {
+		id := id
+		if _, err := c.SendMessage(1, "hi", telegram.WithReplyParameters(telegram.ReplyParameters{MessageID: 5, ChatID: &id})); err == nil || !strings.Contains(err.Error(), "chat id") {
+			t.Errorf("expected %#v to be rejected, got %v", id, err)
+		}
+	}
+	if n := len(s.SentMessages()); n != 3 {
+		t.Errorf("expected invalid chat ids not to be sent, got %d messages", n)
+	}
+}
@@ -0,0 +1,509 @@
+package telegram
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"regexp"
+	"strings"
+	"time"
+)
+
+//go:generate go run ./internal/gen
+
+type Option func(params map[string]interface{})
+
+const chatActionInterval = 4 * time.Second
+
+type ChatAction string
+
+const (
+	ChatActionTyping          ChatAction = "typing"
+	ChatActionUploadPhoto     ChatAction = "upload_photo"
+	ChatActionRecordVideo     ChatAction = "record_video"
+	ChatActionUploadVideo     ChatAction = "upload_video"
+	ChatActionRecordVoice     ChatAction = "record_voice"
+	ChatActionUploadVoice     ChatAction = "upload_voice"
+	ChatActionUploadDocument  ChatAction = "upload_document"
+	ChatActionChooseSticker   ChatAction = "choose_sticker"
+	ChatActionFindLocation    ChatAction = "find_location"
+	ChatActionRecordVideoNote ChatAction = "record_video_note"
+	ChatActionUploadVideoNote ChatAction = "upload_video_note"
+)
+
+const maxAnsweredCallbacks = 1024
+const maxDeleteMessages = 100
+
+var startLinkRegexp = regexp.MustCompile(`^(https?://)?(t\.me|telegram\.me)/([A-Za-z0-9_]+)\?start=`)
+
+func WithText(text string) Option { return func(p map[string]interface{}) { p["text"] = text } }
+
+func WithShowAlert(showAlert bool) Option {
+	return func(p map[string]interface{}) { p["show_alert"] = showAlert }
+}
+
+func WithURL(url string) Option { return func(p map[string]interface{}) { p["url"] = url } }
+
+func WithReplyMarkup(markup interface{}) Option {
+	return func(p map[string]interface{}) { p["reply_markup"] = markup }
+}
+
+func WithOnlyIfBanned(onlyIfBanned bool) Option {
+	return func(p map[string]interface{}) { p["only_if_banned"] = onlyIfBanned }
+}
+
+func WithCacheTime(seconds int) Option {
+	return func(p map[string]interface{}) { p["cache_time"] = seconds }
+}
+
+func WithCaption(caption string) Option {
+	return func(p map[string]interface{}) { p["caption"] = caption }
+}
+
+func WithCaptionEntities(entities []MessageEntity) Option {
+	return func(p map[string]interface{}) { p["caption_entities"] = entities }
+}
+
+func WithParseMode(mode ParseMode) Option {
+	return func(p map[string]interface{}) { p["parse_mode"] = string(mode) }
+}
+
+func WithReplyToMessageID(messageID int) Option {
+	return func(p map[string]interface{}) { p["reply_to_message_id"] = messageID }
+}
+
+// WithMessageEffect adds a message effect, which only works in private chats.
+func WithMessageEffect(effectID string) Option {
+	return func(p map[string]interface{}) { p["message_effect_id"] = effectID }
+}
+
+func WithDisableNotification(disableNotification bool) Option {
+	return func(p map[string]interface{}) { p["disable_notification"] = disableNotification }
+}
+
+func (c *Connection) AnswerCallbackQuery(query CallbackQuery, opts ...Option) error {
+	params := applyOptions(map[string]interface{}{"callback_query_id": query.ID}, opts)
+	if url, ok := params["url"].(string); ok && query.GameShortName == "" {
+		if m := startLinkRegexp.FindStringSubmatch(url); m == nil || !strings.EqualFold(m[3], c.User().Username) {
+			return fmt.Errorf("answerCallbackQuery: url is only allowed for callback_game buttons or t.me/%s?start= links: %s", c.User().Username, url)
+		}
+	}
+	if !c.reserveAnswer(query.ID) {
+		c.debugLog("answerCallbackQuery", []byte("suppressed second answer for "+query.ID))
+		return nil
+	}
+	if err := c.Call("answerCallbackQuery", params, nil); err != nil {
+		c.releaseAnswer(query.ID)
+		return err
+	}
+	return nil
+}
+
+func validateChatAction(action ChatAction) error {
+	switch action {
+	case ChatActionTyping, ChatActionUploadPhoto, ChatActionRecordVideo, ChatActionUploadVideo,
+		ChatActionRecordVoice, ChatActionUploadVoice, ChatActionUploadDocument, ChatActionChooseSticker,
+		ChatActionFindLocation, ChatActionRecordVideoNote, ChatActionUploadVideoNote:
+		return nil
+	}
+	return fmt.Errorf("sendChatAction: unknown action %q", action)
+}
+
+// reserveAnswer marks callbackQueryID as answered unless it already is, so
+// concurrent answers to the same query only send one.
+func (c *Connection) reserveAnswer(callbackQueryID string) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if _, ok := c.answered[callbackQueryID]; ok {
+		return false
+	}
+	if c.answered == nil {
+		c.answered = map[string]struct{}{}
+	}
+	c.answered[callbackQueryID] = struct{}{}
+	c.answeredOrder = append(c.answeredOrder, callbackQueryID)
+	if len(c.answeredOrder) > maxAnsweredCallbacks {
+		delete(c.answered, c.answeredOrder[0])
+		c.answeredOrder = c.answeredOrder[1:]
+	}
+	return true
+}
+
+// releaseAnswer undoes reserveAnswer after a failed answer, so it can be
+// retried.
+func (c *Connection) releaseAnswer(callbackQueryID string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	delete(c.answered, callbackQueryID)
+	for i, id := range c.answeredOrder {
+		if id == callbackQueryID {
+			c.answeredOrder = append(c.answeredOrder[:i], c.answeredOrder[i+1:]...)
+			break
+		}
+	}
+}
+
+func (c *Connection) SetMyCommands(commands []BotCommand, scope *BotCommandScope, languageCode string) error {
+	return c.Call("setMyCommands", commandParams(map[string]interface{}{"commands": commands}, scope, languageCode), nil)
+}
+
+func (c *Connection) GetMyCommands(scope *BotCommandScope, languageCode string) ([]BotCommand, error) {
+	commands := []BotCommand{}
+	err := c.Call("getMyCommands", commandParams(map[string]interface{}{}, scope, languageCode), &commands)
+	return commands, err
+}
+
+// EffectiveCommands returns the command menu Telegram shows the user in the chat,
+// i.e. the first non-empty list of the applicable scopes and languages in the
+// order documented under "Determining list of commands". A chatID equal to the
+// userID denotes the private chat with the user.
+func (c *Connection) EffectiveCommands(chatID, userID int64, languageCode string) ([]BotCommand, error) {
+	scopes := []BotCommandScope{{Type: "chat", ChatID: chatID}, {Type: "all_private_chats"}, {Type: "default"}}
+	if chatID != userID {
+		member, err := c.GetChatMember(chatID, userID)
+		if err != nil {
+			return nil, err
+		}
+		isAdmin := member.Status == "creator" || member.Status == "administrator"
+		scopes = []BotCommandScope{{Type: "chat_member", ChatID: chatID, UserID: userID}}
+		if isAdmin {
+			scopes = append(scopes, BotCommandScope{Type: "chat_administrators", ChatID: chatID})
+		}
+		scopes = append(scopes, BotCommandScope{Type: "chat", ChatID: chatID})
+		if isAdmin {
+			scopes = append(scopes, BotCommandScope{Type: "all_chat_administrators"})
+		}
+		scopes = append(scopes, BotCommandScope{Type: "all_group_chats"}, BotCommandScope{Type: "default"})
+	}
+	languageCodes := []string{""}
+	if languageCode != "" {
+		languageCodes = []string{languageCode, ""}
+	}
+	for i := range scopes {
+		for _, code := range languageCodes {
+			commands, err := c.GetMyCommands(&scopes[i], code)
+			if err != nil || len(commands) != 0 {
+				return commands, err
+			}
+		}
+	}
+	return []BotCommand{}, nil
+}
+
+func (c *Connection) GetMyShortDescription(languageCode string) (BotShortDescription, error) {
+	description, params := BotShortDescription{}, map[string]interface{}{}
+	if languageCode != "" {
+		params["language_code"] = languageCode
+	}
+	err := c.Call("getMyShortDescription", params, &description)
+	return description, err
+}
+
+func (c *Connection) EditUserStarSubscription(userID int64, telegramPaymentChargeID string, isCanceled bool) error {
+	return c.Call("editUserStarSubscription", map[string]interface{}{
+		"user_id":                    userID,
+		"telegram_payment_charge_id": telegramPaymentChargeID,
+		"is_canceled":                isCanceled,
+	}, nil)
+}
+
+// SendMessage rejects empty text. A message consisting only of entities, e.g.
+// a single custom emoji, still needs placeholder text for them to cover.
+func (c *Connection) SendMessage(chatID int64, text string, opts ...Option) (Message, error) {
+	m := Message{}
+	if err := validateText("sendMessage", text); err != nil {
+		return m, err
+	}
+	err := c.Call("sendMessage", applyOptions(map[string]interface{}{"chat_id": chatID, "text": text}, opts), &m)
+	return m, err
+}
+
+func (c *Connection) PinChatMessage(chatID int64, messageID int, opts ...Option) error {
+	return c.Call("pinChatMessage", applyOptions(map[string]interface{}{"chat_id": chatID, "message_id": messageID}, opts), nil)
+}
+
+// SendAndPin sends a message and pins it without notifying members. If pinning
+// fails, the sent message is returned along with the error.
+func (c *Connection) SendAndPin(chatID int64, text string, opts ...Option) (Message, error) {
+	m, err := c.SendMessage(chatID, text, opts...)
+	if err != nil {
+		return m, err
+	}
+	if err := c.PinChatMessage(chatID, m.ID, WithDisableNotification(true)); err != nil {
+		return m, fmt.Errorf("pin message %d: %w", m.ID, err)
+	}
+	return m, nil
+}
+
+func (c *Connection) CopyMessage(chatID, fromChatID int64, messageID int, opts ...Option) (MessageID, error) {
+	id, params := MessageID{}, applyOptions(map[string]interface{}{"chat_id": chatID, "from_chat_id": fromChatID, "message_id": messageID}, opts)
+	_, hasCaption := params["caption"]
+	_, hasMode := params["parse_mode"]
+	_, hasEntities := params["caption_entities"]
+	if hasCaption && !hasMode && !hasEntities && c.ParseMode != ParseModeNone {
+		params["parse_mode"] = string(c.ParseMode)
+	}
+	err := c.Call("copyMessage", params, &id)
+	return id, err
+}
+
+func (c *Connection) SendChatAction(chatID int64, action ChatAction, opts ...Option) error {
+	return c.sendChatAction(context.Background(), chatID, action, opts)
+}
+
+// WithChatAction shows action in the chat while fn runs. Telegram clears chat
+// actions after 5 seconds, so it is re-sent every chatActionInterval until fn
+// returns. Failing to send the action is logged and doesn't affect fn.
+func (c *Connection) WithChatAction(ctx context.Context, chatID int64, action ChatAction, fn func() error) error {
+	if err := validateChatAction(action); err != nil {
+		return err
+	} else if err := c.sendChatAction(ctx, chatID, action, nil); err != nil {
+		c.logf("%s", err)
+	}
+	ctx, cancel := context.WithCancel(ctx)
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		ticker := time.NewTicker(chatActionInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				if err := c.sendChatAction(ctx, chatID, action, nil); err != nil && ctx.Err() == nil {
+					c.logf("%s", err)
+				}
+			}
+		}
+	}()
+	defer func() {
+		cancel()
+		<-done
+	}()
+	return fn()
+}
+
+func (c *Connection) sendChatAction(ctx context.Context, chatID int64, action ChatAction, opts []Option) error {
+	if err := validateChatAction(action); err != nil {
+		return err
+	}
+	return c.CallContext(ctx, "sendChatAction", applyOptions(map[string]interface{}{"chat_id": chatID, "action": string(action)}, opts), nil)
+}
+
+func (c *Connection) EditMessageText(chatID int64, messageID int, text string, opts ...Option) (Message, error) {
+	m := Message{}
+	if err := validateText("editMessageText", text); err != nil {
+		return m, err
+	}
+	err := c.Call("editMessageText", applyOptions(map[string]interface{}{
+		"chat_id":    chatID,
+		"message_id": messageID,
+		"text":       text,
+	}, opts), &m)
+	return m, err
+}
+
+func (c *Connection) EditInlineMessageText(inlineMessageID, text string, opts ...Option) error {
+	if err := validateText("editMessageText", text); err != nil {
+		return err
+	}
+	return c.Call("editMessageText", applyOptions(map[string]interface{}{
+		"inline_message_id": inlineMessageID,
+		"text":              text,
+	}, opts), nil)
+}
+
+func (c *Connection) EditMessageReplyMarkup(chatID int64, messageID int, markup interface{}) (Message, error) {
+	m := Message{}
+	err := c.Call("editMessageReplyMarkup", map[string]interface{}{
+		"chat_id":      chatID,
+		"message_id":   messageID,
+		"reply_markup": markup,
+	}, &m)
+	return m, err
+}
+
+func (c *Connection) EditInlineMessageReplyMarkup(inlineMessageID string, markup interface{}) error {
+	return c.Call("editMessageReplyMarkup", map[string]interface{}{
+		"inline_message_id": inlineMessageID,
+		"reply_markup":      markup,
+	}, nil)
+}
+
+func (c *Connection) GetChat(chatID int64) (Chat, error) {
+	chat := Chat{}
+	err := c.Call("getChat", map[string]interface{}{"chat_id": chatID}, &chat)
+	return chat, err
+}
+
+func (c *Connection) GetPinnedMessage(chatID int64) (*Message, error) {
+	chat, err := c.GetChat(chatID)
+	return chat.PinnedMessage, err
+}
+
+type memberCount struct {
+	count   int
+	fetched time.Time
+}
+
+func (c *Connection) GetChatMemberCount(chatID int64) (int, error) {
+	c.mu.Lock()
+	cached, ok := c.memberCounts[chatID]
+	c.mu.Unlock()
+	if ok && time.Since(cached.fetched) < c.MemberCountTTL {
+		return cached.count, nil
+	}
+	count := 0
+	if err := c.Call("getChatMemberCount", map[string]interface{}{"chat_id": chatID}, &count); err != nil {
+		return 0, err
+	}
+	if c.MemberCountTTL > 0 {
+		c.mu.Lock()
+		if c.memberCounts == nil {
+			c.memberCounts = map[int64]memberCount{}
+		}
+		c.memberCounts[chatID] = memberCount{count, time.Now()}
+		c.mu.Unlock()
+	}
+	return count, nil
+}
+
+func (c *Connection) GetChatMember(chatID, userID int64) (ChatMember, error) {
+	member := ChatMember{}
+	err := c.Call("getChatMember", map[string]interface{}{"chat_id": chatID, "user_id": userID}, &member)
+	return member, err
+}
+
+func (c *Connection) GetChatAdministrators(chatID int64) ([]ChatMember, error) {
+	members := []ChatMember{}
+	err := c.Call("getChatAdministrators", map[string]interface{}{"chat_id": chatID}, &members)
+	return members, err
+}
+
+func (c *Connection) BanChatMember(chatID, userID int64, opts ...Option) error {
+	return c.Call("banChatMember", applyOptions(map[string]interface{}{
+		"chat_id": chatID,
+		"user_id": userID,
+	}, opts), nil)
+}
+
+// WithUntilDate ends a ban or restriction at t. Less than 30 seconds or more
+// than 366 days from now count as forever.
+func WithUntilDate(t time.Time) Option {
+	return func(p map[string]interface{}) { p["until_date"] = t.Unix() }
+}
+
+func WithRevokeMessages(revokeMessages bool) Option {
+	return func(p map[string]interface{}) { p["revoke_messages"] = revokeMessages }
+}
+
+func (c *Connection) SetChatTitle(chatID int64, title string) error {
+	return c.Call("setChatTitle", map[string]interface{}{"chat_id": chatID, "title": title}, nil)
+}
+
+func (c *Connection) CreateChatInviteLink(chatID int64, opts ...Option) (ChatInviteLink, error) {
+	link := ChatInviteLink{}
+	err := c.Call("createChatInviteLink", applyOptions(map[string]interface{}{"chat_id": chatID}, opts), &link)
+	return link, err
+}
+
+func WithName(name string) Option {
+	return func(p map[string]interface{}) { p["name"] = name }
+}
+
+func WithExpireDate(t time.Time) Option {
+	return func(p map[string]interface{}) { p["expire_date"] = t.Unix() }
+}
+
+func WithMemberLimit(memberLimit int) Option {
+	return func(p map[string]interface{}) { p["member_limit"] = memberLimit }
+}
+
+// WithCreatesJoinRequest makes users joining via the link send a join request
+// that administrators have to approve; it can't be combined with a member limit.
+func WithCreatesJoinRequest(createsJoinRequest bool) Option {
+	return func(p map[string]interface{}) { p["creates_join_request"] = createsJoinRequest }
+}
+
+func (c *Connection) UnbanChatMember(chatID, userID int64, opts ...Option) error {
+	return c.Call("unbanChatMember", applyOptions(map[string]interface{}{
+		"chat_id": chatID,
+		"user_id": userID,
+	}, opts), nil)
+}
+
+func (c *Connection) SetChatPhoto(chatID int64, photo io.Reader) error {
+	return c.Call("setChatPhoto", map[string]interface{}{"chat_id": chatID, "photo": photo}, nil)
+}
+
+func (c *Connection) DeleteChatPhoto(chatID int64) error {
+	return c.Call("deleteChatPhoto", map[string]interface{}{"chat_id": chatID}, nil)
+}
+
+func (c *Connection) StopPoll(chatID int64, messageID int, opts ...Option) (Poll, error) {
+	poll := Poll{}
+	err := c.Call("stopPoll", applyOptions(map[string]interface{}{
+		"chat_id":    chatID,
+		"message_id": messageID,
+	}, opts), &poll)
+	return poll, err
+}
+
+func (c *Connection) GetStarTransactions(offset, limit int) (StarTransactions, error) {
+	transactions := StarTransactions{}
+	err := c.Call("getStarTransactions", map[string]interface{}{"offset": offset, "limit": limit}, &transactions)
+	return transactions, err
+}
+
+func (c *Connection) DeleteMessage(chatID int64, messageID int) error {
+	return c.Call("deleteMessage", map[string]interface{}{"chat_id": chatID, "message_id": messageID}, nil)
+}
+
+func (c *Connection) DeleteMessages(chatID int64, messageIDs []int) ([]int, map[int]error) {
+	deleted, failed := []int{}, map[int]error{}
+	for len(messageIDs) > 0 {
+		n := maxDeleteMessages
+		if len(messageIDs) < n {
+			n = len(messageIDs)
+		}
+		batch := messageIDs[:n]
+		messageIDs = messageIDs[n:]
+		if err := c.Call("deleteMessages", map[string]interface{}{"chat_id": chatID, "message_ids": batch}, nil); err == nil {
+			deleted = append(deleted, batch...)
+			continue
+		}
+		for _, id := range batch {
+			if err := c.DeleteMessage(chatID, id); err != nil {
+				failed[id] = err
+			} else {
+				deleted = append(deleted, id)
+			}
+		}
+	}
+	return deleted, failed
+}
+
+func validateText(method, text string) error {
+	if strings.TrimSpace(text) == "" {
+		return fmt.Errorf("%s: text must not be empty or whitespace only", method)
+	}
+	return nil
+}
+
+func commandParams(params map[string]interface{}, scope *BotCommandScope, languageCode string) map[string]interface{} {
+	if scope != nil {
+		params["scope"] = scope
+	}
+	if languageCode != "" {
+		params["language_code"] = languageCode
+	}
+	return params
+}
+
+func applyOptions(params map[string]interface{}, opts []Option) map[string]interface{} {
+	for _, opt := range opts {
+		opt(params)
+	}
+	return params
+}
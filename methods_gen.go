@@ -0,0 +1,116 @@
+// Code generated by internal/gen from Bot API 7.10; DO NOT EDIT.
+
+package telegram
+
+// ApproveChatJoinRequest calls approveChatJoinRequest. Use this method to approve a chat join request. Returns True on success.
+func (c *Connection) ApproveChatJoinRequest(chatID int64, userID int64) error {
+	return c.Call("approveChatJoinRequest", map[string]interface{}{"chat_id": chatID, "user_id": userID}, nil)
+}
+
+// BanChatSenderChat calls banChatSenderChat. Use this method to ban a channel chat in a supergroup or a channel. Returns True on success.
+func (c *Connection) BanChatSenderChat(chatID int64, senderChatID int64) error {
+	return c.Call("banChatSenderChat", map[string]interface{}{"chat_id": chatID, "sender_chat_id": senderChatID}, nil)
+}
+
+// Close calls close. Use this method to close the bot instance before moving it from one local server to another. Returns True on success.
+func (c *Connection) Close() error {
+	return c.Call("close", nil, nil)
+}
+
+// CloseGeneralForumTopic calls closeGeneralForumTopic. Use this method to close an open 'General' topic in a forum supergroup chat. Returns True on success.
+func (c *Connection) CloseGeneralForumTopic(chatID int64) error {
+	return c.Call("closeGeneralForumTopic", map[string]interface{}{"chat_id": chatID}, nil)
+}
+
+// DeclineChatJoinRequest calls declineChatJoinRequest. Use this method to decline a chat join request. Returns True on success.
+func (c *Connection) DeclineChatJoinRequest(chatID int64, userID int64) error {
+	return c.Call("declineChatJoinRequest", map[string]interface{}{"chat_id": chatID, "user_id": userID}, nil)
+}
+
+// DeleteChatStickerSet calls deleteChatStickerSet. Use this method to delete a group sticker set from a supergroup. Returns True on success.
+func (c *Connection) DeleteChatStickerSet(chatID int64) error {
+	return c.Call("deleteChatStickerSet", map[string]interface{}{"chat_id": chatID}, nil)
+}
+
+// ExportChatInviteLink calls exportChatInviteLink. Use this method to generate a new primary invite link for a chat; any previously generated primary link is revoked. Returns the new invite link as String on success.
+func (c *Connection) ExportChatInviteLink(chatID int64) (string, error) {
+	r := ""
+	err := c.Call("exportChatInviteLink", map[string]interface{}{"chat_id": chatID}, &r)
+	return r, err
+}
+
+// GetMyDefaultAdministratorRights calls getMyDefaultAdministratorRights. Use this method to get the current default administrator rights of the bot. Returns ChatAdministratorRights on success.
+func (c *Connection) GetMyDefaultAdministratorRights(opts ...Option) (ChatAdministratorRights, error) {
+	r := ChatAdministratorRights{}
+	err := c.Call("getMyDefaultAdministratorRights", applyOptions(map[string]interface{}{}, opts), &r)
+	return r, err
+}
+
+// HideGeneralForumTopic calls hideGeneralForumTopic. Use this method to hide the 'General' topic in a forum supergroup chat. Returns True on success.
+func (c *Connection) HideGeneralForumTopic(chatID int64) error {
+	return c.Call("hideGeneralForumTopic", map[string]interface{}{"chat_id": chatID}, nil)
+}
+
+// LeaveChat calls leaveChat. Use this method for your bot to leave a group, supergroup or channel. Returns True on success.
+func (c *Connection) LeaveChat(chatID int64) error {
+	return c.Call("leaveChat", map[string]interface{}{"chat_id": chatID}, nil)
+}
+
+// LogOut calls logOut. Use this method to log out from the cloud Bot API server before launching the bot locally. Returns True on success.
+func (c *Connection) LogOut() error {
+	return c.Call("logOut", nil, nil)
+}
+
+// ReopenGeneralForumTopic calls reopenGeneralForumTopic. Use this method to reopen a closed 'General' topic in a forum supergroup chat. Returns True on success.
+func (c *Connection) ReopenGeneralForumTopic(chatID int64) error {
+	return c.Call("reopenGeneralForumTopic", map[string]interface{}{"chat_id": chatID}, nil)
+}
+
+// RevokeChatInviteLink calls revokeChatInviteLink. Use this method to revoke an invite link created by the bot. Returns the revoked invite link as ChatInviteLink object.
+func (c *Connection) RevokeChatInviteLink(chatID int64, inviteLink string) (ChatInviteLink, error) {
+	r := ChatInviteLink{}
+	err := c.Call("revokeChatInviteLink", map[string]interface{}{"chat_id": chatID, "invite_link": inviteLink}, &r)
+	return r, err
+}
+
+// SendDice calls sendDice. Use this method to send an animated emoji that will display a random value. On success, the sent Message is returned.
+func (c *Connection) SendDice(chatID int64, opts ...Option) (Message, error) {
+	r := Message{}
+	err := c.Call("sendDice", applyOptions(map[string]interface{}{"chat_id": chatID}, opts), &r)
+	return r, err
+}
+
+// SetChatDescription calls setChatDescription. Use this method to change the description of a group, a supergroup or a channel. Returns True on success.
+func (c *Connection) SetChatDescription(chatID int64, opts ...Option) error {
+	return c.Call("setChatDescription", applyOptions(map[string]interface{}{"chat_id": chatID}, opts), nil)
+}
+
+// SetChatStickerSet calls setChatStickerSet. Use this method to set a new group sticker set for a supergroup. Returns True on success.
+func (c *Connection) SetChatStickerSet(chatID int64, stickerSetName string) error {
+	return c.Call("setChatStickerSet", map[string]interface{}{"chat_id": chatID, "sticker_set_name": stickerSetName}, nil)
+}
+
+// UnbanChatSenderChat calls unbanChatSenderChat. Use this method to unban a previously banned channel chat in a supergroup or channel. Returns True on success.
+func (c *Connection) UnbanChatSenderChat(chatID int64, senderChatID int64) error {
+	return c.Call("unbanChatSenderChat", map[string]interface{}{"chat_id": chatID, "sender_chat_id": senderChatID}, nil)
+}
+
+// UnhideGeneralForumTopic calls unhideGeneralForumTopic. Use this method to unhide the 'General' topic in a forum supergroup chat. Returns True on success.
+func (c *Connection) UnhideGeneralForumTopic(chatID int64) error {
+	return c.Call("unhideGeneralForumTopic", map[string]interface{}{"chat_id": chatID}, nil)
+}
+
+// UnpinAllChatMessages calls unpinAllChatMessages. Use this method to clear the list of pinned messages in a chat. Returns True on success.
+func (c *Connection) UnpinAllChatMessages(chatID int64) error {
+	return c.Call("unpinAllChatMessages", map[string]interface{}{"chat_id": chatID}, nil)
+}
+
+// UnpinAllForumTopicMessages calls unpinAllForumTopicMessages. Use this method to clear the list of pinned messages in a forum topic. Returns True on success.
+func (c *Connection) UnpinAllForumTopicMessages(chatID int64, messageThreadID int) error {
+	return c.Call("unpinAllForumTopicMessages", map[string]interface{}{"chat_id": chatID, "message_thread_id": messageThreadID}, nil)
+}
+
+// UnpinChatMessage calls unpinChatMessage. Use this method to remove a message from the list of pinned messages in a chat. Returns True on success.
+func (c *Connection) UnpinChatMessage(chatID int64, opts ...Option) error {
+	return c.Call("unpinChatMessage", applyOptions(map[string]interface{}{"chat_id": chatID}, opts), nil)
+}
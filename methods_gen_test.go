@@ -0,0 +1,37 @@
+package telegram_test
+
+import (
+	"testing"
+
+	"github.com/niklasfasching/telegram"
+	"github.com/niklasfasching/telegram/telegramtest"
+)
+
+func TestGeneratedMethods(t *testing.T) {
+	s := telegramtest.NewServer()
+	defer s.Close()
+	s.Respond("revokeChatInviteLink", telegram.ChatInviteLink{InviteLink: "https://t.me/+abc", IsRevoked: true})
+	c := s.Connection()
+	if err := c.LeaveChat(-100123); err != nil {
+		t.Fatal(err)
+	}
+	link, err := c.RevokeChatInviteLink(-100123, "https://t.me/+abc")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !link.IsRevoked {
+		t.Errorf("got %+v, want a revoked link", link)
+	}
+	if err := c.UnpinChatMessage(-100123, func(p map[string]interface{}) { p["message_id"] = 7 }); err != nil {
+		t.Fatal(err)
+	}
+	if got := s.Calls("leaveChat")[0].Params["chat_id"]; got != "-100123" {
+		t.Errorf("leaveChat chat_id: got %q", got)
+	}
+	if got := s.Calls("revokeChatInviteLink")[0].Params["invite_link"]; got != "https://t.me/+abc" {
+		t.Errorf("revokeChatInviteLink invite_link: got %q", got)
+	}
+	if got := s.Calls("unpinChatMessage")[0].Params["message_id"]; got != "7" {
+		t.Errorf("unpinChatMessage message_id: got %q", got)
+	}
+}
@@ -0,0 +1,521 @@
+package telegram_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/niklasfasching/telegram"
+	"github.com/niklasfasching/telegram/telegramtest"
+)
+
+// fakeCommands makes s keep the commands set per scope and language, like the
+// Bot API does. A missing scope is the default scope.
+func fakeCommands(s *telegramtest.Server) {
+	commands := map[string]json.RawMessage{}
+	key := func(call telegramtest.Call) string {
+		scope := call.Params["scope"]
+		if scope == "" {
+			scope = `{"type":"default"}`
+		}
+		return scope + "|" + call.Params["language_code"]
+	}
+	s.RespondFunc("setMyCommands", func(call telegramtest.Call) interface{} {
+		commands[key(call)] = json.RawMessage(call.Params["commands"])
+		return true
+	})
+	s.RespondFunc("getMyCommands", func(call telegramtest.Call) interface{} {
+		if cs, ok := commands[key(call)]; ok {
+			return cs
+		}
+		return []telegram.BotCommand{}
+	})
+}
+
+func TestMyCommandsRoundTrip(t *testing.T) {
+	s := telegramtest.NewServer()
+	defer s.Close()
+	fakeCommands(s)
+	c := s.Connection()
+	scopes := []*telegram.BotCommandScope{
+		nil,
+		{Type: "all_private_chats"},
+		{Type: "chat", ChatID: -100123},
+		{Type: "chat_member", ChatID: -100123, UserID: 42},
+	}
+	for i, scope := range scopes {
+		commands := []telegram.BotCommand{{Command: "cmd" + string(rune('a'+i)), Description: "scope " + string(rune('a'+i))}}
+		if err := c.SetMyCommands(commands, scope, "de"); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for i, scope := range scopes {
+		commands, err := c.GetMyCommands(scope, "de")
+		if err != nil {
+			t.Fatal(err)
+		}
+		want := []telegram.BotCommand{{Command: "cmd" + string(rune('a'+i)), Description: "scope " + string(rune('a'+i))}}
+		if !reflect.DeepEqual(commands, want) {
+			t.Errorf("scope %+v: got %v, want %v", scope, commands, want)
+		}
+	}
+	if commands, err := c.GetMyCommands(scopes[1], ""); err != nil || len(commands) != 0 {
+		t.Errorf("expected no commands for another language, got %v (%v)", commands, err)
+	}
+}
+
+func TestGetMyShortDescription(t *testing.T) {
+	s := telegramtest.NewServer()
+	defer s.Close()
+	s.Respond("getMyShortDescription", map[string]string{"short_description": "A bot"})
+	d, err := s.Connection().GetMyShortDescription("en")
+	if err != nil {
+		t.Fatal(err)
+	} else if d.ShortDescription != "A bot" {
+		t.Errorf("unexpected description %+v", d)
+	} else if lang := s.Calls("getMyShortDescription")[0].Params["language_code"]; lang != "en" {
+		t.Errorf("expected language_code en, got %q", lang)
+	}
+}
+
+func TestEditUserStarSubscription(t *testing.T) {
+	s := telegramtest.NewServer()
+	defer s.Close()
+	s.Respond("editUserStarSubscription", true)
+	if err := s.Connection().EditUserStarSubscription(42, "charge", true); err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]string{"user_id": "42", "telegram_payment_charge_id": "charge", "is_canceled": "true"}
+	if got := s.Calls("editUserStarSubscription")[0].Params; !reflect.DeepEqual(got, want) {
+		t.Errorf("got params %v, want %v", got, want)
+	}
+}
+
+func TestAnswerCallbackQueryURL(t *testing.T) {
+	s := telegramtest.NewServer()
+	defer s.Close()
+	c := s.Connection()
+	go c.Start()
+	defer c.StopAndWait()
+	for deadline := time.Now().Add(time.Second); c.User().Username == ""; time.Sleep(10 * time.Millisecond) {
+		if time.Now().After(deadline) {
+			t.Fatal("timed out waiting for getMe")
+		}
+	}
+	game := telegram.CallbackQuery{ID: "1", GameShortName: "tetris"}
+	if err := c.AnswerCallbackQuery(game, telegram.WithURL("https://example.com/tetris")); err != nil {
+		t.Fatal(err)
+	}
+	login := telegram.CallbackQuery{ID: "2", Data: "login"}
+	if err := c.AnswerCallbackQuery(login, telegram.WithURL("https://t.me/Test_Bot?start=abc")); err != nil {
+		t.Fatal(err)
+	}
+	urls := []string{}
+	for _, call := range s.Calls("answerCallbackQuery") {
+		urls = append(urls, call.Params["url"])
+	}
+	if want := []string{"https://example.com/tetris", "https://t.me/Test_Bot?start=abc"}; !reflect.DeepEqual(urls, want) {
+		t.Errorf("got urls %v, want %v", urls, want)
+	}
+	for _, url := range []string{"https://example.com/tetris", "https://t.me/other_bot?start=abc"} {
+		if err := c.AnswerCallbackQuery(telegram.CallbackQuery{ID: "3"}, telegram.WithURL(url)); err == nil || !strings.Contains(err.Error(), "url is only allowed") {
+			t.Errorf("expected %s to be rejected, got %v", url, err)
+		}
+	}
+	if n := len(s.Calls("answerCallbackQuery")); n != 2 {
+		t.Errorf("expected rejected answers not to be sent, got %d calls", n)
+	}
+}
+
+func TestChatPhoto(t *testing.T) {
+	s := telegramtest.NewServer()
+	defer s.Close()
+	c := s.Connection()
+	s.Respond("getChat", json.RawMessage(`{"id": -100, "type": "supergroup", "photo": {"small_file_id": "small", "small_file_unique_id": "s", "big_file_id": "big", "big_file_unique_id": "b"}}`))
+	chat, err := c.GetChat(-100)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := &telegram.ChatPhoto{SmallFileID: "small", SmallFileUniqueID: "s", BigFileID: "big", BigFileUniqueID: "b"}
+	if !reflect.DeepEqual(chat.Photo, want) {
+		t.Errorf("got photo %#v, want %#v", chat.Photo, want)
+	}
+	if err := c.DeleteChatPhoto(-100); err != nil {
+		t.Fatal(err)
+	} else if got := s.Calls("deleteChatPhoto")[0].Params; !reflect.DeepEqual(got, map[string]string{"chat_id": "-100"}) {
+		t.Errorf("unexpected params %v", got)
+	}
+}
+
+func TestStopPoll(t *testing.T) {
+	s := telegramtest.NewServer()
+	defer s.Close()
+	s.Respond("stopPoll", json.RawMessage(`{"id": "p", "question": "?", "options": [{"text": "a", "voter_count": 2}, {"text": "b", "voter_count": 1}], "total_voter_count": 3, "is_closed": true, "type": "regular"}`))
+	poll, err := s.Connection().StopPoll(1, 5)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := telegram.Poll{ID: "p", Question: "?", Options: []telegram.PollOption{{"a", 2}, {"b", 1}}, TotalVoterCount: 3, IsClosed: true, Type: "regular"}
+	if !reflect.DeepEqual(poll, want) {
+		t.Errorf("got poll %#v, want %#v", poll, want)
+	}
+	if got := s.Calls("stopPoll")[0].Params; got["chat_id"] != "1" || got["message_id"] != "5" {
+		t.Errorf("unexpected params %v", got)
+	}
+}
+
+func TestUnbanChatMember(t *testing.T) {
+	s := telegramtest.NewServer()
+	defer s.Close()
+	c := s.Connection()
+	if err := c.UnbanChatMember(-100, 42); err != nil {
+		t.Fatal(err)
+	}
+	if err := c.UnbanChatMember(-100, 42, telegram.WithOnlyIfBanned(true)); err != nil {
+		t.Fatal(err)
+	}
+	calls := s.Calls("unbanChatMember")
+	if _, ok := calls[0].Params["only_if_banned"]; ok {
+		t.Errorf("expected only_if_banned to be unset by default, got %v", calls[0].Params)
+	}
+	if got := calls[1].Params["only_if_banned"]; got != "true" {
+		t.Errorf("expected only_if_banned to be forwarded, got %q", got)
+	}
+}
+
+func TestAnswerCallbackQueryOnce(t *testing.T) {
+	s := telegramtest.NewServer()
+	defer s.Close()
+	c := s.Connection()
+	q := telegram.CallbackQuery{ID: "1"}
+	for i := 0; i < 2; i++ {
+		if err := c.AnswerCallbackQuery(q, telegram.WithText("done")); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := c.AnswerCallbackQuery(telegram.CallbackQuery{ID: "2"}); err != nil {
+		t.Fatal(err)
+	}
+	calls := s.Calls("answerCallbackQuery")
+	if len(calls) != 2 || calls[0].Params["callback_query_id"] != "1" || calls[1].Params["callback_query_id"] != "2" {
+		t.Errorf("expected the second answer for 1 to be suppressed, got %v", calls)
+	}
+
+	answered := 0
+	c.HandleCallback("vote:", func(ctx context.Context, q telegram.CallbackQuery, payload string) error {
+		answered++
+		return c.AnswerCallbackQuery(q, telegram.WithText("voted for "+payload))
+	})
+	h := &telegramtest.Harness{Connection: c}
+	if d := h.Inject(`{"update_id": 1, "callback_query": {"id": "3", "from": {"id": 1}, "data": "vote:a"}}`); d.Err != nil {
+		t.Fatal(d.Err)
+	}
+	if calls := s.Calls("answerCallbackQuery"); answered != 1 || len(calls) != 3 || calls[2].Params["text"] != "voted for a" {
+		t.Errorf("expected the automatic answer to be suppressed after the manual one, got %v", calls)
+	}
+}
+
+func TestAnswerCallbackQueryConcurrently(t *testing.T) {
+	s := telegramtest.NewServer()
+	defer s.Close()
+	c := s.Connection()
+	c.MaxRetries = -1
+	q := telegram.CallbackQuery{ID: "1"}
+	wg := sync.WaitGroup{}
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if err := c.AnswerCallbackQuery(q); err != nil {
+				t.Error(err)
+			}
+		}()
+	}
+	wg.Wait()
+	if calls := s.Calls("answerCallbackQuery"); len(calls) != 1 {
+		t.Errorf("expected concurrent answers to send one, got %d", len(calls))
+	}
+
+	s.RespondError("answerCallbackQuery", 500, "Internal Server Error")
+	q = telegram.CallbackQuery{ID: "2"}
+	if err := c.AnswerCallbackQuery(q); err == nil {
+		t.Fatal("expected the error response")
+	}
+	s.Reset("answerCallbackQuery")
+	if err := c.AnswerCallbackQuery(q); err != nil {
+		t.Fatal(err)
+	}
+	if calls := s.Calls("answerCallbackQuery"); len(calls) != 3 {
+		t.Errorf("expected a failed answer to be retried, got %d calls", len(calls))
+	}
+}
+
+func TestGetStarTransactions(t *testing.T) {
+	s := telegramtest.NewServer()
+	defer s.Close()
+	s.Respond("getStarTransactions", json.RawMessage(`{"transactions": [
+		{"id": "1", "amount": 10, "date": 1, "source": {"type": "user", "user": {"id": 42}, "invoice_payload": "pro"}},
+		{"id": "2", "amount": 5, "date": 2, "receiver": {"type": "fragment", "withdrawal_state": {"type": "succeeded", "date": 3, "url": "https://fragment.com/tx"}}},
+		{"id": "3", "amount": 1, "date": 4, "receiver": {"type": "telegram_ads"}}
+	]}`))
+	transactions, err := s.Connection().GetStarTransactions(10, 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []telegram.StarTransaction{
+		{ID: "1", Amount: 10, Date: 1, Source: &telegram.TransactionPartner{Type: telegram.TransactionPartnerUser, User: &telegram.User{ID: 42}, InvoicePayload: "pro"}},
+		{ID: "2", Amount: 5, Date: 2, Receiver: &telegram.TransactionPartner{Type: telegram.TransactionPartnerFragment, WithdrawalState: &telegram.RevenueWithdrawalState{Type: "succeeded", Date: 3, URL: "https://fragment.com/tx"}}},
+		{ID: "3", Amount: 1, Date: 4, Receiver: &telegram.TransactionPartner{Type: telegram.TransactionPartnerTelegramAds}},
+	}
+	if !reflect.DeepEqual(transactions.Transactions, want) {
+		t.Errorf("got %#v, want %#v", transactions.Transactions, want)
+	}
+	if params := s.Calls("getStarTransactions")[0].Params; params["offset"] != "10" || params["limit"] != "3" {
+		t.Errorf("unexpected params %v", params)
+	}
+}
+
+func TestDeleteMessages(t *testing.T) {
+	s := telegramtest.NewServer()
+	defer s.Close()
+	s.RespondError("deleteMessages", 400, "Bad Request: message can't be deleted")
+	s.RespondFunc("deleteMessage", func(call telegramtest.Call) interface{} {
+		if call.Params["message_id"] == "2" {
+			return telegramtest.Error{Code: 400, Description: "Bad Request: message can't be deleted for everyone"}
+		}
+		return true
+	})
+	deleted, failed := s.Connection().DeleteMessages(1, []int{1, 2, 3})
+	if !reflect.DeepEqual(deleted, []int{1, 3}) {
+		t.Errorf("got deleted %v, want [1 3]", deleted)
+	}
+	if e := (*telegram.APIError)(nil); len(failed) != 1 || !errors.As(failed[2], &e) || e.ErrorCode != 400 {
+		t.Errorf("expected message 2 to fail, got %v", failed)
+	}
+	if batches := s.Calls("deleteMessages"); len(batches) != 1 || batches[0].Params["message_ids"] != "[1,2,3]" {
+		t.Errorf("expected a single batch delete first, got %v", batches)
+	}
+
+	s.Reset("deleteMessages")
+	ids := make([]int, 150)
+	for i := range ids {
+		ids[i] = i + 1
+	}
+	if deleted, failed := s.Connection().DeleteMessages(1, ids); len(deleted) != 150 || len(failed) != 0 {
+		t.Errorf("expected all messages to be deleted, got %d deleted and %v", len(deleted), failed)
+	} else if n := len(s.Calls("deleteMessages")); n != 3 {
+		t.Errorf("expected 2 batches of at most 100 messages, got %d calls", n-1)
+	}
+}
+
+func TestGetPinnedMessage(t *testing.T) {
+	s := telegramtest.NewServer()
+	defer s.Close()
+	c := s.Connection()
+	s.Respond("getChat", json.RawMessage(`{"id": 1, "type": "group", "pinned_message": {"message_id": 5, "chat": {"id": 1}, "text": "rules", "reply_to_message": {"message_id": 4, "chat": {"id": 1}, "text": "see below"}}}`))
+	m, err := c.GetPinnedMessage(1)
+	if err != nil {
+		t.Fatal(err)
+	} else if m == nil || m.ID != 5 || m.Text != "rules" || m.ReplyToMessage == nil || m.ReplyToMessage.Text != "see below" {
+		t.Errorf("unexpected pinned message %#v", m)
+	}
+	s.Respond("getChat", json.RawMessage(`{"id": 1, "type": "group"}`))
+	if m, err := c.GetPinnedMessage(1); err != nil || m != nil {
+		t.Errorf("expected no pinned message and no error, got %#v, %v", m, err)
+	}
+}
+
+func TestSendMessageEmptyText(t *testing.T) {
+	s := telegramtest.NewServer()
+	defer s.Close()
+	c := s.Connection()
+	for _, text := range []string{"", " ", "\n\t "} {
+		if _, err := c.SendMessage(1, text); err == nil || !strings.Contains(err.Error(), "must not be empty") {
+			t.Errorf("expected %q to be rejected, got %v", text, err)
+		}
+		if _, err := (telegram.Message{Chat: telegram.Chat{ID: 1}}).Reply(c, text); err == nil {
+			t.Errorf("expected Reply to reject %q", text)
+		}
+	}
+	emoji := []telegram.MessageEntity{{Type: "custom_emoji", Offset: 0, Length: 2, CustomEmojiID: "1"}}
+	if _, err := c.SendMessage(1, "👍", telegram.WithEntities(emoji)); err != nil {
+		t.Fatal(err)
+	} else if texts := s.SentMessages(); len(texts) != 1 || texts[0] != "👍" {
+		t.Errorf("expected only the placeholder message to be sent, got %q", texts)
+	}
+}
+
+func TestCopyMessageCaption(t *testing.T) {
+	s := telegramtest.NewServer()
+	defer s.Close()
+	s.Respond("copyMessage", telegram.MessageID{MessageID: 9})
+	c := s.Connection()
+	c.ParseMode = telegram.ParseModeHTML
+	entities := []telegram.MessageEntity{{Type: "bold", Offset: 0, Length: 3}}
+	copies := [][]telegram.Option{
+		nil,
+		{telegram.WithCaption("<b>new</b>")},
+		{telegram.WithCaption("*new*"), telegram.WithParseMode(telegram.ParseModeMarkdownV2)},
+		{telegram.WithCaption("new"), telegram.WithCaptionEntities(entities)},
+	}
+	for _, opts := range copies {
+		if id, err := c.CopyMessage(1, 2, 3, opts...); err != nil {
+			t.Fatal(err)
+		} else if id.MessageID != 9 {
+			t.Errorf("unexpected id %#v", id)
+		}
+	}
+	want := []map[string]string{
+		{"chat_id": "1", "from_chat_id": "2", "message_id": "3"},
+		{"chat_id": "1", "from_chat_id": "2", "message_id": "3", "caption": "<b>new</b>", "parse_mode": "HTML"},
+		{"chat_id": "1", "from_chat_id": "2", "message_id": "3", "caption": "*new*", "parse_mode": "MarkdownV2"},
+		{"chat_id": "1", "from_chat_id": "2", "message_id": "3", "caption": "new", "caption_entities": ""},
+	}
+	for i, call := range s.Calls("copyMessage") {
+		if sent := []telegram.MessageEntity{}; call.Params["caption_entities"] != "" {
+			if err := json.Unmarshal([]byte(call.Params["caption_entities"]), &sent); err != nil || !reflect.DeepEqual(sent, entities) {
+				t.Errorf("copy %d: got caption_entities %s", i, call.Params["caption_entities"])
+			}
+			call.Params["caption_entities"] = ""
+		}
+		if !reflect.DeepEqual(call.Params, want[i]) {
+			t.Errorf("copy %d: got %v, want %v", i, call.Params, want[i])
+		}
+	}
+}
+
+func TestSendChatAction(t *testing.T) {
+	s := telegramtest.NewServer()
+	defer s.Close()
+	c := s.Connection()
+	actions := map[telegram.ChatAction]string{
+		telegram.ChatActionTyping:          "typing",
+		telegram.ChatActionUploadPhoto:     "upload_photo",
+		telegram.ChatActionRecordVideo:     "record_video",
+		telegram.ChatActionUploadVideo:     "upload_video",
+		telegram.ChatActionRecordVoice:     "record_voice",
+		telegram.ChatActionUploadVoice:     "upload_voice",
+		telegram.ChatActionUploadDocument:  "upload_document",
+		telegram.ChatActionChooseSticker:   "choose_sticker",
+		telegram.ChatActionFindLocation:    "find_location",
+		telegram.ChatActionRecordVideoNote: "record_video_note",
+		telegram.ChatActionUploadVideoNote: "upload_video_note",
+	}
+	for action, want := range actions {
+		if err := c.SendChatAction(1, action); err != nil {
+			t.Fatal(err)
+		}
+		calls := s.Calls("sendChatAction")
+		if got := calls[len(calls)-1].Params["action"]; got != want {
+			t.Errorf("got action %q, want %q", got, want)
+		}
+	}
+	if err := c.SendChatAction(1, "typnig"); err == nil || !strings.Contains(err.Error(), "unknown action") {
+		t.Errorf("expected a typo to be rejected, got %v", err)
+	} else if n := len(s.Calls("sendChatAction")); n != len(actions) {
+		t.Errorf("expected the typo not to be sent, got %d calls", n)
+	}
+}
+
+func TestEffectiveCommands(t *testing.T) {
+	s := telegramtest.NewServer()
+	defer s.Close()
+	fakeCommands(s)
+	s.RespondFunc("getChatMember", func(call telegramtest.Call) interface{} {
+		if call.Params["user_id"] == "1" {
+			return telegram.ChatMember{Status: "administrator"}
+		}
+		return telegram.ChatMember{Status: "member"}
+	})
+	c := s.Connection()
+	help := telegram.BotCommand{Command: "help", Description: "help"}
+	hilfe := telegram.BotCommand{Command: "help", Description: "hilfe"}
+	ban := telegram.BotCommand{Command: "ban", Description: "ban"}
+	for _, set := range []struct {
+		commands []telegram.BotCommand
+		scope    *telegram.BotCommandScope
+		language string
+	}{
+		{[]telegram.BotCommand{help}, nil, ""},
+		{[]telegram.BotCommand{hilfe}, nil, "de"},
+		{[]telegram.BotCommand{help, ban}, &telegram.BotCommandScope{Type: "chat_administrators", ChatID: -100}, ""},
+	} {
+		if err := c.SetMyCommands(set.commands, set.scope, set.language); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for _, test := range []struct {
+		chatID, userID int64
+		language       string
+		want           []telegram.BotCommand
+	}{
+		{-100, 1, "", []telegram.BotCommand{help, ban}},
+		{-100, 1, "de", []telegram.BotCommand{help, ban}},
+		{-100, 2, "", []telegram.BotCommand{help}},
+		{-100, 2, "de", []telegram.BotCommand{hilfe}},
+		{-200, 1, "", []telegram.BotCommand{help}},
+		{2, 2, "de", []telegram.BotCommand{hilfe}},
+	} {
+		got, err := c.EffectiveCommands(test.chatID, test.userID, test.language)
+		if err != nil {
+			t.Fatal(err)
+		} else if !reflect.DeepEqual(got, test.want) {
+			t.Errorf("chat %d, user %d, language %q: got %v, want %v", test.chatID, test.userID, test.language, got, test.want)
+		}
+	}
+}
+
+func TestGetChatMemberCountTTL(t *testing.T) {
+	s := telegramtest.NewServer()
+	defer s.Close()
+	s.RespondFunc("getChatMemberCount", func(call telegramtest.Call) interface{} { return len(s.Calls("getChatMemberCount")) * 10 })
+	c := s.Connection()
+	c.MemberCountTTL = 100 * time.Millisecond
+	for i := 0; i < 5; i++ {
+		for _, chatID := range []int64{-1, -2} {
+			if _, err := c.GetChatMemberCount(chatID); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+	if n := len(s.Calls("getChatMemberCount")); n != 2 {
+		t.Errorf("expected one request per chat within the TTL, got %d", n)
+	}
+	time.Sleep(150 * time.Millisecond)
+	if count, err := c.GetChatMemberCount(-1); err != nil || count != 30 {
+		t.Errorf("expected a fresh count after the TTL, got %d %v", count, err)
+	}
+	c.MemberCountTTL = 0
+	c.GetChatMemberCount(-2)
+	c.GetChatMemberCount(-2)
+	if n := len(s.Calls("getChatMemberCount")); n != 5 {
+		t.Errorf("expected no caching without a TTL, got %d requests", n)
+	}
+}
+
+func TestSendAndPin(t *testing.T) {
+	s := telegramtest.NewServer()
+	defer s.Close()
+	s.Respond("sendMessage", telegram.Message{ID: 42, Chat: telegram.Chat{ID: -100}, Text: "news"})
+	c := s.Connection()
+	if m, err := c.SendAndPin(-100, "news"); err != nil || m.ID != 42 {
+		t.Fatalf("got %v %v", m, err)
+	}
+	pins := s.Calls("pinChatMessage")
+	if len(pins) != 1 || pins[0].Params["chat_id"] != "-100" || pins[0].Params["message_id"] != "42" || pins[0].Params["disable_notification"] != "true" {
+		t.Errorf("unexpected pins %v", pins)
+	}
+	s.RespondError("pinChatMessage", 400, "Bad Request: not enough rights to manage pinned messages in the chat")
+	m, err := c.SendAndPin(-100, "news")
+	if e := (*telegram.APIError)(nil); !errors.As(err, &e) || e.ErrorCode != 400 || !strings.Contains(err.Error(), "pin message 42") {
+		t.Errorf("expected the pin failure, got %v", err)
+	}
+	if m.ID != 42 || len(s.SentMessages()) != 2 {
+		t.Errorf("expected the sent message along with the pin failure, got %v", m)
+	}
+}
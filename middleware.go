@@ -0,0 +1,18 @@
+package telegram
+
+import "context"
+
+type Handler func(ctx context.Context, u Update) error
+
+type Middleware func(next Handler) Handler
+
+// Use wraps the dispatch of every update, including commands and topics, in the
+// given middleware; the first one registered runs outermost. A middleware that
+// doesn't call next stops the update from being handled. next always dispatches
+// the update as received, changes to the Update passed to it are not seen by
+// the handlers.
+func (c *Connection) Use(middleware ...Middleware) {
+	c.handlersMu.Lock()
+	defer c.handlersMu.Unlock()
+	c.middleware = append(c.middleware, middleware...)
+}
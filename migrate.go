@@ -0,0 +1,48 @@
+package telegram
+
+import (
+	"bytes"
+	"encoding/json"
+)
+
+// ChatMigrated moves the per-chat state kept by the connection from a group to
+// the supergroup it was upgraded to and then calls OnChatMigrated, if set, so
+// state kept outside of the connection can be moved as well. It is called
+// automatically for migrate_to_chat_id service messages and for API errors
+// reporting a migration, in which case the failed call is retried once with the
+// new chat id.
+func (c *Connection) ChatMigrated(oldID, newID int64) {
+	c.mu.Lock()
+	if s, ok := c.slowMode[oldID]; ok {
+		c.slowMode[newID] = s
+		delete(c.slowMode, oldID)
+	}
+	delete(c.memberCounts, oldID)
+	for _, m := range c.polls {
+		if m.Chat.ID == oldID {
+			m.Chat.ID = newID
+		}
+	}
+	c.mu.Unlock()
+	c.handlersMu.Lock()
+	for key, fn := range c.topics {
+		if key.chatID == oldID {
+			c.topics[topic{newID, key.threadID}] = fn
+			delete(c.topics, key)
+		}
+	}
+	c.handlersMu.Unlock()
+	if c.OnChatMigrated != nil {
+		c.OnChatMigrated(oldID, newID)
+	}
+}
+
+func (c *Connection) migrateFromUpdate(update map[string]json.RawMessage) {
+	if !bytes.Contains(update["message"], []byte(`"migrate_to_chat_id"`)) {
+		return
+	}
+	m := Message{}
+	if err := json.Unmarshal(update["message"], &m); err == nil && m.MigrateToChatID != 0 {
+		c.ChatMigrated(m.Chat.ID, m.MigrateToChatID)
+	}
+}
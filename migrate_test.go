@@ -0,0 +1,39 @@
+package telegram_test
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"github.com/niklasfasching/telegram"
+	"github.com/niklasfasching/telegram/telegramtest"
+)
+
+func TestChatMigrated(t *testing.T) {
+	s := telegramtest.NewServer()
+	defer s.Close()
+	s.Respond("sendPoll", telegram.Message{ID: 7, Chat: telegram.Chat{ID: -1}, Poll: &telegram.Poll{ID: "poll"}})
+	c := s.Connection()
+	c.TrackPolls = true
+	migrations, topics := [][2]int64{}, []int64{}
+	c.OnChatMigrated = func(oldID, newID int64) { migrations = append(migrations, [2]int64{oldID, newID}) }
+	c.HandleTopic(-1, 5, func(ctx context.Context, m telegram.Message) error {
+		topics = append(topics, m.Chat.ID)
+		return nil
+	})
+	if _, err := c.SendPoll(-1, "?", []string{"a", "b"}); err != nil {
+		t.Fatal(err)
+	}
+	h := &telegramtest.Harness{Connection: c}
+	h.Inject(`{"update_id": 1, "message": {"message_id": 8, "chat": {"id": -1, "type": "group"}, "migrate_to_chat_id": -100}}`)
+	if want := [][2]int64{{-1, -100}}; !reflect.DeepEqual(migrations, want) {
+		t.Errorf("got migrations %v, want %v", migrations, want)
+	}
+	if m, ok := c.PollMessage("poll"); !ok || m.Chat.ID != -100 {
+		t.Errorf("expected the tracked poll to move to the new chat, got %v %v", m, ok)
+	}
+	update := `{"update_id": 2, "message": {"message_id": 1, "message_thread_id": 5, "is_topic_message": true, "chat": {"id": -100, "type": "supergroup", "is_forum": true}, "text": "hi"}}`
+	if d := h.Inject(update); d.Err != nil || d.Route != "topic:-100/5" || !reflect.DeepEqual(topics, []int64{-100}) {
+		t.Errorf("expected the topic route to move to the new chat, got %q (%v) and %v", d.Route, d.Err, topics)
+	}
+}
@@ -1,21 +1,537 @@
 package telegram
 
+type Update struct {
+	ID                int                `json:"update_id"`
+	Message           *Message           `json:"message"`
+	EditedMessage     *Message           `json:"edited_message"`
+	ChannelPost       *Message           `json:"channel_post"`
+	EditedChannelPost *Message           `json:"edited_channel_post"`
+	InlineQuery       *InlineQuery       `json:"inline_query"`
+	CallbackQuery     *CallbackQuery     `json:"callback_query"`
+	Poll              *Poll              `json:"poll"`
+	ChatBoost         *ChatBoostUpdated  `json:"chat_boost"`
+	RemovedChatBoost  *ChatBoostRemoved  `json:"removed_chat_boost"`
+	MyChatMember      *ChatMemberUpdated `json:"my_chat_member"`
+	ChatMember        *ChatMemberUpdated `json:"chat_member"`
+
+	ChosenInlineResult *ChosenInlineResult `json:"chosen_inline_result"`
+	PollAnswer         *PollAnswer         `json:"poll_answer"`
+	ShippingQuery      *ShippingQuery      `json:"shipping_query"`
+	PreCheckoutQuery   *PreCheckoutQuery   `json:"pre_checkout_query"`
+
+	MessageReaction      *MessageReactionUpdated      `json:"message_reaction"`
+	MessageReactionCount *MessageReactionCountUpdated `json:"message_reaction_count"`
+}
+
+type MessageID struct {
+	MessageID int `json:"message_id"`
+}
+
 type User struct {
-	ID        int    `json:"id"`
+	ID        int64  `json:"id"`
 	FirstName string `json:"first_name"`
+	LastName  string `json:"last_name"`
 	Username  string `json:"username"`
 	IsBot     bool   `json:"is_bot"`
+	IsPremium bool   `json:"is_premium"`
+
+	CanJoinGroups           bool `json:"can_join_groups"`
+	CanReadAllGroupMessages bool `json:"can_read_all_group_messages"`
+	SupportsInlineQueries   bool `json:"supports_inline_queries"`
 }
 
 type Message struct {
-	ID   int    `json:"message_id"`
-	From User   `json:"from"`
+	ID                int                `json:"message_id"`
+	From              User               `json:"from"`
+	SenderChat        *Chat              `json:"sender_chat"`
+	Date              int                `json:"date"`
+	EditDate          int                `json:"edit_date"`
+	Text              string             `json:"text"`
+	ReplyToMessage    *Message           `json:"reply_to_message"`
+	SuccessfulPayment *SuccessfulPayment `json:"successful_payment"`
+	Chat              Chat               `json:"chat"`
+	Entities          []MessageEntity    `json:"entities"`
+	Story             *Story             `json:"story"`
+	ChatBackgroundSet *ChatBackground    `json:"chat_background_set"`
+	ChatShared        *ChatShared        `json:"chat_shared"`
+	UsersShared       *UsersShared       `json:"users_shared"`
+	Sticker           *Sticker           `json:"sticker"`
+	Poll              *Poll              `json:"poll"`
+	MessageThreadID   int                `json:"message_thread_id"`
+	IsTopicMessage    bool               `json:"is_topic_message"`
+
+	ProximityAlertTriggered *ProximityAlertTriggered `json:"proximity_alert_triggered"`
+	ForwardOrigin           *MessageOrigin           `json:"forward_origin"`
+	ForwardDate             int                      `json:"forward_date"`
+	ForwardFrom             *User                    `json:"forward_from"`
+	ForwardFromChat         *Chat                    `json:"forward_from_chat"`
+	ForwardFromMessageID    int                      `json:"forward_from_message_id"`
+	ForwardSignature        string                   `json:"forward_signature"`
+	ForwardSenderName       string                   `json:"forward_sender_name"`
+	IsAutomaticForward      bool                     `json:"is_automatic_forward"`
+	HasProtectedContent     bool                     `json:"has_protected_content"`
+	BoostAdded              *ChatBoostAdded          `json:"boost_added"`
+	MigrateToChatID         int64                    `json:"migrate_to_chat_id"`
+	MigrateFromChatID       int64                    `json:"migrate_from_chat_id"`
+
+	Caption         string          `json:"caption"`
+	CaptionEntities []MessageEntity `json:"caption_entities"`
+	MediaGroupID    string          `json:"media_group_id"`
+	AuthorSignature string          `json:"author_signature"`
+	Photo           []PhotoSize     `json:"photo"`
+	Document        *Document       `json:"document"`
+	Audio           *Audio          `json:"audio"`
+	Video           *Video          `json:"video"`
+	Animation       *Animation      `json:"animation"`
+	Voice           *Voice          `json:"voice"`
+	VideoNote       *VideoNote      `json:"video_note"`
+	Contact         *Contact        `json:"contact"`
+	Location        *Location       `json:"location"`
+	Venue           *Venue          `json:"venue"`
+	NewChatMembers  []User          `json:"new_chat_members"`
+	LeftChatMember  *User           `json:"left_chat_member"`
+	NewChatTitle    string          `json:"new_chat_title"`
+	NewChatPhoto    []PhotoSize     `json:"new_chat_photo"`
+	PinnedMessage   *Message        `json:"pinned_message"`
+	WebAppData      *WebAppData     `json:"web_app_data"`
+
+	ForumTopicCreated  *ForumTopicCreated  `json:"forum_topic_created"`
+	ForumTopicEdited   *ForumTopicEdited   `json:"forum_topic_edited"`
+	ForumTopicClosed   *ForumTopicClosed   `json:"forum_topic_closed"`
+	ForumTopicReopened *ForumTopicReopened `json:"forum_topic_reopened"`
+}
+
+type ChatBoostAdded struct {
+	BoostCount int `json:"boost_count"`
+}
+
+type Chat struct {
+	ID        int64      `json:"id"`
+	Type      string     `json:"type"`
+	Title     string     `json:"title"`
+	FirstName string     `json:"first_name"`
+	LastName  string     `json:"last_name"`
+	Username  string     `json:"username"`
+	Photo     *ChatPhoto `json:"photo"`
+	IsForum   bool       `json:"is_forum"`
+
+	LinkedChatID  int64    `json:"linked_chat_id"`
+	SlowModeDelay int      `json:"slow_mode_delay"`
+	PinnedMessage *Message `json:"pinned_message"`
+
+	AvailableReactions  []ReactionType `json:"available_reactions"`
+	HasProtectedContent bool           `json:"has_protected_content"`
+}
+
+type ReactionType struct {
+	Type          string `json:"type"`
+	Emoji         string `json:"emoji,omitempty"`
+	CustomEmojiID string `json:"custom_emoji_id,omitempty"`
+}
+
+type ChatPhoto struct {
+	SmallFileID       string `json:"small_file_id"`
+	SmallFileUniqueID string `json:"small_file_unique_id"`
+	BigFileID         string `json:"big_file_id"`
+	BigFileUniqueID   string `json:"big_file_unique_id"`
+}
+
+type CallbackQuery struct {
+	ID              string   `json:"id"`
+	From            User     `json:"from"`
+	Message         *Message `json:"message"`
+	InlineMessageID string   `json:"inline_message_id"`
+	ChatInstance    string   `json:"chat_instance"`
+	Data            string   `json:"data"`
+	GameShortName   string   `json:"game_short_name"`
+}
+
+type InlineQuery struct {
+	ID       string `json:"id"`
+	From     User   `json:"from"`
+	Query    string `json:"query"`
+	Offset   string `json:"offset"`
+	ChatType string `json:"chat_type"`
+}
+
+// ChosenInlineResult is only sent for bots with inline feedback enabled via
+// @BotFather. InlineMessageID is set if the result had an inline keyboard.
+type ChosenInlineResult struct {
+	ResultID        string `json:"result_id"`
+	From            User   `json:"from"`
+	Query           string `json:"query"`
+	InlineMessageID string `json:"inline_message_id"`
+}
+
+type MessageEntity struct {
+	Type          string `json:"type"`
+	Offset        int    `json:"offset"`
+	Length        int    `json:"length"`
+	URL           string `json:"url"`
+	User          *User  `json:"user"`
+	Language      string `json:"language"`
+	CustomEmojiID string `json:"custom_emoji_id"`
+}
+
+type BotCommand struct {
+	Command     string `json:"command"`
+	Description string `json:"description"`
+}
+
+type BotCommandScope struct {
+	Type   string `json:"type"`
+	ChatID int64  `json:"chat_id,omitempty"`
+	UserID int64  `json:"user_id,omitempty"`
+}
+
+type BotShortDescription struct {
+	ShortDescription string `json:"short_description"`
+}
+
+type SuccessfulPayment struct {
+	Currency                   string `json:"currency"`
+	TotalAmount                int    `json:"total_amount"`
+	InvoicePayload             string `json:"invoice_payload"`
+	SubscriptionExpirationDate int    `json:"subscription_expiration_date"`
+	IsRecurring                bool   `json:"is_recurring"`
+	IsFirstRecurring           bool   `json:"is_first_recurring"`
+	ShippingOptionID           string `json:"shipping_option_id"`
+	TelegramPaymentChargeID    string `json:"telegram_payment_charge_id"`
+	ProviderPaymentChargeID    string `json:"provider_payment_charge_id"`
+
+	OrderInfo *OrderInfo `json:"order_info"`
+}
+
+const (
+	ChatBoostSourcePremium  = "premium"
+	ChatBoostSourceGiftCode = "gift_code"
+	ChatBoostSourceGiveaway = "giveaway"
+)
+
+type ChatBoostSource struct {
+	Source            string `json:"source"`
+	User              *User  `json:"user"`
+	GiveawayMessageID int    `json:"giveaway_message_id"`
+	PrizeStarCount    int    `json:"prize_star_count"`
+	IsUnclaimed       bool   `json:"is_unclaimed"`
+}
+
+type ChatBoost struct {
+	BoostID        string          `json:"boost_id"`
+	AddDate        int             `json:"add_date"`
+	ExpirationDate int             `json:"expiration_date"`
+	Source         ChatBoostSource `json:"source"`
+}
+
+type ChatBoostUpdated struct {
+	Chat  Chat      `json:"chat"`
+	Boost ChatBoost `json:"boost"`
+}
+
+type ChatBoostRemoved struct {
+	Chat       Chat            `json:"chat"`
+	BoostID    string          `json:"boost_id"`
+	RemoveDate int             `json:"remove_date"`
+	Source     ChatBoostSource `json:"source"`
+}
+
+type Story struct {
+	Chat Chat `json:"chat"`
+	ID   int  `json:"id"`
+}
+
+type Document struct {
+	FileID       string `json:"file_id"`
+	FileUniqueID string `json:"file_unique_id"`
+	FileName     string `json:"file_name"`
+	MimeType     string `json:"mime_type"`
+	FileSize     int64  `json:"file_size"`
+}
+
+type ChatBackground struct {
+	Type BackgroundType `json:"type"`
+}
+
+type BackgroundType struct {
+	Type             string          `json:"type"`
+	Fill             *BackgroundFill `json:"fill"`
+	DarkThemeDimming int             `json:"dark_theme_dimming"`
+	Document         *Document       `json:"document"`
+	IsBlurred        bool            `json:"is_blurred"`
+	IsMoving         bool            `json:"is_moving"`
+	Intensity        int             `json:"intensity"`
+	IsInverted       bool            `json:"is_inverted"`
+	ThemeName        string          `json:"theme_name"`
+}
+
+type BackgroundFill struct {
+	Type          string `json:"type"`
+	Color         int    `json:"color"`
+	TopColor      int    `json:"top_color"`
+	BottomColor   int    `json:"bottom_color"`
+	RotationAngle int    `json:"rotation_angle"`
+	Colors        []int  `json:"colors"`
+}
+
+type ChatShared struct {
+	RequestID int    `json:"request_id"`
+	ChatID    int64  `json:"chat_id"`
+	Title     string `json:"title"`
+	Username  string `json:"username"`
+}
+
+type UsersShared struct {
+	RequestID int          `json:"request_id"`
+	Users     []SharedUser `json:"users"`
+}
+
+type SharedUser struct {
+	UserID    int64       `json:"user_id"`
+	FirstName string      `json:"first_name"`
+	LastName  string      `json:"last_name"`
+	Username  string      `json:"username"`
+	Photo     []PhotoSize `json:"photo"`
+}
+
+type Audio struct {
+	FileID       string     `json:"file_id"`
+	FileUniqueID string     `json:"file_unique_id"`
+	Duration     int        `json:"duration"`
+	Performer    string     `json:"performer"`
+	Title        string     `json:"title"`
+	FileName     string     `json:"file_name"`
+	MimeType     string     `json:"mime_type"`
+	FileSize     int64      `json:"file_size"`
+	Thumbnail    *PhotoSize `json:"thumbnail"`
+}
+
+type Video struct {
+	FileID       string     `json:"file_id"`
+	FileUniqueID string     `json:"file_unique_id"`
+	Width        int        `json:"width"`
+	Height       int        `json:"height"`
+	Duration     int        `json:"duration"`
+	FileName     string     `json:"file_name"`
+	MimeType     string     `json:"mime_type"`
+	FileSize     int64      `json:"file_size"`
+	Thumbnail    *PhotoSize `json:"thumbnail"`
+}
+
+type Animation struct {
+	FileID       string     `json:"file_id"`
+	FileUniqueID string     `json:"file_unique_id"`
+	Width        int        `json:"width"`
+	Height       int        `json:"height"`
+	Duration     int        `json:"duration"`
+	FileName     string     `json:"file_name"`
+	MimeType     string     `json:"mime_type"`
+	FileSize     int64      `json:"file_size"`
+	Thumbnail    *PhotoSize `json:"thumbnail"`
+}
+
+type Voice struct {
+	FileID       string `json:"file_id"`
+	FileUniqueID string `json:"file_unique_id"`
+	Duration     int    `json:"duration"`
+	MimeType     string `json:"mime_type"`
+	FileSize     int64  `json:"file_size"`
+}
+
+type VideoNote struct {
+	FileID       string     `json:"file_id"`
+	FileUniqueID string     `json:"file_unique_id"`
+	Length       int        `json:"length"`
+	Duration     int        `json:"duration"`
+	FileSize     int64      `json:"file_size"`
+	Thumbnail    *PhotoSize `json:"thumbnail"`
+}
+
+type Contact struct {
+	PhoneNumber string `json:"phone_number"`
+	FirstName   string `json:"first_name"`
+	LastName    string `json:"last_name"`
+	UserID      int64  `json:"user_id"`
+	VCard       string `json:"vcard"`
+}
+
+type Location struct {
+	Latitude             float64 `json:"latitude"`
+	Longitude            float64 `json:"longitude"`
+	HorizontalAccuracy   float64 `json:"horizontal_accuracy"`
+	LivePeriod           int     `json:"live_period"`
+	Heading              int     `json:"heading"`
+	ProximityAlertRadius int     `json:"proximity_alert_radius"`
+}
+
+type Venue struct {
+	Location Location `json:"location"`
+	Title    string   `json:"title"`
+	Address  string   `json:"address"`
+}
+
+type ChatMemberUpdated struct {
+	Chat          Chat       `json:"chat"`
+	From          User       `json:"from"`
+	Date          int        `json:"date"`
+	OldChatMember ChatMember `json:"old_chat_member"`
+	NewChatMember ChatMember `json:"new_chat_member"`
+}
+
+type PhotoSize struct {
+	FileID       string `json:"file_id"`
+	FileUniqueID string `json:"file_unique_id"`
+	Width        int    `json:"width"`
+	Height       int    `json:"height"`
+	FileSize     int64  `json:"file_size"`
+}
+
+type KeyboardButton struct {
+	Text         string                      `json:"text"`
+	RequestChat  *KeyboardButtonRequestChat  `json:"request_chat,omitempty"`
+	RequestUsers *KeyboardButtonRequestUsers `json:"request_users,omitempty"`
+	WebApp       *WebAppInfo                 `json:"web_app,omitempty"`
+}
+
+type KeyboardButtonRequestUsers struct {
+	RequestID       int   `json:"request_id"`
+	UserIsBot       *bool `json:"user_is_bot,omitempty"`
+	UserIsPremium   *bool `json:"user_is_premium,omitempty"`
+	MaxQuantity     int   `json:"max_quantity,omitempty"`
+	RequestName     bool  `json:"request_name,omitempty"`
+	RequestUsername bool  `json:"request_username,omitempty"`
+	RequestPhoto    bool  `json:"request_photo,omitempty"`
+}
+
+type KeyboardButtonRequestChat struct {
+	RequestID       int  `json:"request_id"`
+	ChatIsChannel   bool `json:"chat_is_channel"`
+	ChatIsForum     bool `json:"chat_is_forum,omitempty"`
+	ChatHasUsername bool `json:"chat_has_username,omitempty"`
+	ChatIsCreated   bool `json:"chat_is_created,omitempty"`
+	BotIsMember     bool `json:"bot_is_member,omitempty"`
+	RequestTitle    bool `json:"request_title,omitempty"`
+	RequestUsername bool `json:"request_username,omitempty"`
+	RequestPhoto    bool `json:"request_photo,omitempty"`
+}
+
+type File struct {
+	FileID       string `json:"file_id"`
+	FileUniqueID string `json:"file_unique_id"`
+	FileSize     int64  `json:"file_size"`
+	FilePath     string `json:"file_path"`
+}
+
+type Sticker struct {
+	FileID        string `json:"file_id"`
+	FileUniqueID  string `json:"file_unique_id"`
+	Type          string `json:"type"`
+	Width         int    `json:"width"`
+	Height        int    `json:"height"`
+	IsAnimated    bool   `json:"is_animated"`
+	IsVideo       bool   `json:"is_video"`
+	Emoji         string `json:"emoji"`
+	SetName       string `json:"set_name"`
+	CustomEmojiID string `json:"custom_emoji_id"`
+	FileSize      int64  `json:"file_size"`
+}
+
+type Poll struct {
+	ID                    string       `json:"id"`
+	Question              string       `json:"question"`
+	Options               []PollOption `json:"options"`
+	TotalVoterCount       int          `json:"total_voter_count"`
+	IsClosed              bool         `json:"is_closed"`
+	IsAnonymous           bool         `json:"is_anonymous"`
+	Type                  string       `json:"type"`
+	AllowsMultipleAnswers bool         `json:"allows_multiple_answers"`
+	CorrectOptionID       *int         `json:"correct_option_id"`
+	Explanation           string       `json:"explanation"`
+	OpenPeriod            int          `json:"open_period"`
+	CloseDate             int          `json:"close_date"`
+}
+
+type PollOption struct {
+	Text       string `json:"text"`
+	VoterCount int    `json:"voter_count"`
+}
+
+// PollAnswer is sent for non-anonymous polls. VoterChat is set instead of User
+// for votes cast on behalf of a chat; an empty OptionIDs means the vote was
+// retracted.
+type PollAnswer struct {
+	PollID    string `json:"poll_id"`
+	VoterChat *Chat  `json:"voter_chat"`
+	User      *User  `json:"user"`
+	OptionIDs []int  `json:"option_ids"`
+}
+
+type ChatInviteLink struct {
+	InviteLink              string `json:"invite_link"`
+	Creator                 User   `json:"creator"`
+	CreatesJoinRequest      bool   `json:"creates_join_request"`
+	IsPrimary               bool   `json:"is_primary"`
+	IsRevoked               bool   `json:"is_revoked"`
+	Name                    string `json:"name"`
+	ExpireDate              int    `json:"expire_date"`
+	MemberLimit             int    `json:"member_limit"`
+	PendingJoinRequestCount int    `json:"pending_join_request_count"`
+}
+
+type ChatMember struct {
+	Status      string `json:"status"`
+	User        User   `json:"user"`
+	IsAnonymous bool   `json:"is_anonymous"`
+	CustomTitle string `json:"custom_title"`
+	IsMember    bool   `json:"is_member"`
+	UntilDate   int    `json:"until_date"`
+	ChatPermissions
+}
+
+type ProximityAlertTriggered struct {
+	Traveler User `json:"traveler"`
+	Watcher  User `json:"watcher"`
+	Distance int  `json:"distance"`
+}
+
+type StarTransactions struct {
+	Transactions []StarTransaction `json:"transactions"`
+}
+
+type StarTransaction struct {
+	ID       string              `json:"id"`
+	Amount   int                 `json:"amount"`
+	Date     int                 `json:"date"`
+	Source   *TransactionPartner `json:"source"`
+	Receiver *TransactionPartner `json:"receiver"`
+}
+
+const (
+	TransactionPartnerUser        = "user"
+	TransactionPartnerFragment    = "fragment"
+	TransactionPartnerTelegramAds = "telegram_ads"
+	TransactionPartnerOther       = "other"
+)
+
+type TransactionPartner struct {
+	Type            string                  `json:"type"`
+	User            *User                   `json:"user"`
+	InvoicePayload  string                  `json:"invoice_payload"`
+	WithdrawalState *RevenueWithdrawalState `json:"withdrawal_state"`
+}
+
+type RevenueWithdrawalState struct {
+	Type string `json:"type"`
 	Date int    `json:"date"`
-	Text string `json:"text"`
-	Chat struct {
-		ID        int    `json:"id"`
-		FirstName string `json:"first_name"`
-		Type      string `json:"type"`
-		Username  string `json:"username"`
-	} `json:"chat"`
+	URL  string `json:"url"`
+}
+
+type MessageOrigin struct {
+	Type            string `json:"type"`
+	Date            int    `json:"date"`
+	SenderUser      *User  `json:"sender_user"`
+	SenderUserName  string `json:"sender_user_name"`
+	SenderChat      *Chat  `json:"sender_chat"`
+	Chat            *Chat  `json:"chat"`
+	MessageID       int    `json:"message_id"`
+	AuthorSignature string `json:"author_signature"`
 }
@@ -0,0 +1,171 @@
+package telegram_test
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+
+	"github.com/niklasfasching/telegram"
+)
+
+func TestSuccessfulPaymentSubscription(t *testing.T) {
+	m := telegram.Message{}
+	data := `{"message_id": 1, "successful_payment": {"currency": "XTR", "total_amount": 100, "subscription_expiration_date": 1700000000, "is_recurring": true, "is_first_recurring": true, "telegram_payment_charge_id": "charge"}}`
+	if err := json.Unmarshal([]byte(data), &m); err != nil {
+		t.Fatal(err)
+	}
+	p := m.SuccessfulPayment
+	if p == nil || p.SubscriptionExpirationDate != 1700000000 || !p.IsRecurring || !p.IsFirstRecurring || p.TelegramPaymentChargeID != "charge" {
+		t.Errorf("unexpected payment %#v", p)
+	}
+}
+
+func TestChatBoostSource(t *testing.T) {
+	u := telegram.User{}
+	if err := json.Unmarshal([]byte(`{"id": 1, "first_name": "a", "is_premium": true}`), &u); err != nil {
+		t.Fatal(err)
+	} else if !u.IsPremium {
+		t.Error("expected a premium user")
+	}
+	tests := []struct {
+		data string
+		want telegram.ChatBoostSource
+	}{
+		{`{"source": "premium", "user": {"id": 1}}`, telegram.ChatBoostSource{Source: telegram.ChatBoostSourcePremium, User: &telegram.User{ID: 1}}},
+		{`{"source": "gift_code", "user": {"id": 2}}`, telegram.ChatBoostSource{Source: telegram.ChatBoostSourceGiftCode, User: &telegram.User{ID: 2}}},
+		{`{"source": "giveaway", "giveaway_message_id": 3, "prize_star_count": 50, "is_unclaimed": true}`, telegram.ChatBoostSource{Source: telegram.ChatBoostSourceGiveaway, GiveawayMessageID: 3, PrizeStarCount: 50, IsUnclaimed: true}},
+	}
+	for _, test := range tests {
+		u := telegram.Update{}
+		data := `{"update_id": 1, "chat_boost": {"chat": {"id": -100}, "boost": {"boost_id": "b", "source": ` + test.data + `}}}`
+		if err := json.Unmarshal([]byte(data), &u); err != nil {
+			t.Fatal(err)
+		}
+		if got := u.ChatBoost.Boost.Source; !reflect.DeepEqual(got, test.want) {
+			t.Errorf("%s: got %#v, want %#v", test.want.Source, got, test.want)
+		}
+	}
+}
+
+func TestStoryMessage(t *testing.T) {
+	m := telegram.Message{}
+	if err := json.Unmarshal([]byte(`{"message_id": 1, "chat": {"id": 1}, "story": {"chat": {"id": -100, "type": "channel"}, "id": 7}}`), &m); err != nil {
+		t.Fatal(err)
+	} else if m.Story == nil || m.Story.ID != 7 || m.Story.Chat.ID != -100 {
+		t.Errorf("unexpected story %#v", m.Story)
+	}
+	m = telegram.Message{}
+	if err := json.Unmarshal([]byte(`{"message_id": 2, "chat": {"id": 1}, "chat_background_set": {"type": {"type": "chat_theme", "theme_name": "🌷"}}}`), &m); err != nil {
+		t.Fatal(err)
+	} else if b := m.ChatBackgroundSet; b == nil || b.Type.Type != "chat_theme" || b.Type.ThemeName != "🌷" {
+		t.Errorf("unexpected background %#v", b)
+	}
+}
+
+func TestProximityAlertTriggered(t *testing.T) {
+	m := telegram.Message{}
+	data := `{"message_id": 1, "chat": {"id": 1}, "proximity_alert_triggered": {"traveler": {"id": 1, "first_name": "a"}, "watcher": {"id": 2, "first_name": "b"}, "distance": 42}}`
+	if err := json.Unmarshal([]byte(data), &m); err != nil {
+		t.Fatal(err)
+	}
+	want := &telegram.ProximityAlertTriggered{Traveler: telegram.User{ID: 1, FirstName: "a"}, Watcher: telegram.User{ID: 2, FirstName: "b"}, Distance: 42}
+	if !reflect.DeepEqual(m.ProximityAlertTriggered, want) {
+		t.Errorf("got %#v, want %#v", m.ProximityAlertTriggered, want)
+	}
+}
+
+func TestEditDate(t *testing.T) {
+	for _, test := range []struct {
+		update string
+		edited bool
+	}{
+		{`{"update_id": 1, "message": {"message_id": 1, "chat": {"id": 1}, "date": 10, "text": "hi"}}`, false},
+		{`{"update_id": 2, "edited_message": {"message_id": 1, "chat": {"id": 1}, "date": 10, "edit_date": 20, "text": "ho"}}`, true},
+	} {
+		u := telegram.Update{}
+		if err := json.Unmarshal([]byte(test.update), &u); err != nil {
+			t.Fatal(err)
+		}
+		m, _ := u.EffectiveMessage()
+		if m.IsEdited() != test.edited || test.edited && (m.EditDate != 20 || m.EditTime().Unix() != 20) {
+			t.Errorf("update %d: got edit_date %d, IsEdited() = %v", u.ID, m.EditDate, m.IsEdited())
+		}
+	}
+}
+
+func TestUsersShared(t *testing.T) {
+	m := telegram.Message{}
+	data := `{"message_id": 1, "chat": {"id": 1}, "users_shared": {"request_id": 3, "users": [{"user_id": 10, "first_name": "a", "username": "a_"}, {"user_id": 11, "first_name": "b", "last_name": "c"}]}}`
+	if err := json.Unmarshal([]byte(data), &m); err != nil {
+		t.Fatal(err)
+	}
+	want := &telegram.UsersShared{RequestID: 3, Users: []telegram.SharedUser{{UserID: 10, FirstName: "a", Username: "a_"}, {UserID: 11, FirstName: "b", LastName: "c"}}}
+	if !reflect.DeepEqual(m.UsersShared, want) {
+		t.Errorf("got %#v, want %#v", m.UsersShared, want)
+	}
+
+	isBot := false
+	button := (&telegram.RequestTracker{}).UsersButton("pick", telegram.KeyboardButtonRequestUsers{UserIsBot: &isBot, MaxQuantity: 10, RequestName: true}, nil)
+	bs, err := json.Marshal(button)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := string(bs), `{"text":"pick","request_users":{"request_id":1,"user_is_bot":false,"max_quantity":10,"request_name":true}}`; got != want {
+		t.Errorf("got %s, want %s", got, want)
+	}
+}
+
+func TestAutomaticForward(t *testing.T) {
+	post, comment := telegram.Message{}, telegram.Message{}
+	if err := json.Unmarshal([]byte(`{"message_id": 1, "chat": {"id": -200, "type": "supergroup"}, "sender_chat": {"id": -100, "type": "channel", "title": "news"}, "is_automatic_forward": true, "text": "post"}`), &post); err != nil {
+		t.Fatal(err)
+	}
+	if err := json.Unmarshal([]byte(`{"message_id": 2, "chat": {"id": -200, "type": "supergroup"}, "from": {"id": 1}, "text": "comment"}`), &comment); err != nil {
+		t.Fatal(err)
+	}
+	if !post.IsAutomaticForward || post.SenderChat == nil || post.SenderChat.ID != -100 || post.SenderChat.Title != "news" {
+		t.Errorf("unexpected auto-forwarded post %#v", post)
+	}
+	if comment.IsAutomaticForward || comment.SenderChat != nil {
+		t.Errorf("unexpected comment %#v", comment)
+	}
+}
+
+func TestRestrictedChatMember(t *testing.T) {
+	m := telegram.ChatMember{}
+	data := `{"status": "restricted", "user": {"id": 1}, "is_member": true, "until_date": 1700000000, "can_send_messages": true, "can_send_photos": false, "can_invite_users": true}`
+	if err := json.Unmarshal([]byte(data), &m); err != nil {
+		t.Fatal(err)
+	}
+	want := telegram.ChatMember{Status: "restricted", User: telegram.User{ID: 1}, IsMember: true, UntilDate: 1700000000,
+		ChatPermissions: telegram.ChatPermissions{CanSendMessages: true, CanInviteUsers: true}}
+	if !reflect.DeepEqual(m, want) {
+		t.Errorf("got %#v, want %#v", m, want)
+	}
+}
+
+func TestBoostAdded(t *testing.T) {
+	m := telegram.Message{}
+	if err := json.Unmarshal([]byte(`{"message_id": 1, "chat": {"id": -100, "type": "supergroup"}, "sender_boost_count": 2, "boost_added": {"boost_count": 3}}`), &m); err != nil {
+		t.Fatal(err)
+	}
+	if m.BoostAdded == nil || m.BoostAdded.BoostCount != 3 {
+		t.Errorf("unexpected boost_added %#v", m.BoostAdded)
+	}
+}
+
+func TestLegacyForwardFields(t *testing.T) {
+	user, channel := telegram.Message{}, telegram.Message{}
+	if err := json.Unmarshal([]byte(`{"message_id": 1, "forward_date": 1700000000, "forward_from": {"id": 2, "first_name": "Ann"}}`), &user); err != nil {
+		t.Fatal(err)
+	} else if user.ForwardDate != 1700000000 || user.ForwardFrom == nil || user.ForwardFrom.ID != 2 || user.ForwardFromChat != nil {
+		t.Errorf("unexpected user forward %#v", user)
+	}
+	data := `{"message_id": 1, "forward_date": 1700000001, "forward_from_chat": {"id": -100, "type": "channel", "title": "News"}, "forward_from_message_id": 9, "forward_signature": "Editor"}`
+	if err := json.Unmarshal([]byte(data), &channel); err != nil {
+		t.Fatal(err)
+	} else if channel.ForwardDate != 1700000001 || channel.ForwardFromChat == nil || channel.ForwardFromChat.Title != "News" ||
+		channel.ForwardFromMessageID != 9 || channel.ForwardSignature != "Editor" || channel.ForwardFrom != nil {
+		t.Errorf("unexpected channel forward %#v", channel)
+	}
+}
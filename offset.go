@@ -0,0 +1,112 @@
+package telegram
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+type OffsetStore interface {
+	Load() (int, error)
+	Save(offset int) error
+}
+
+// FileOffsetStore keeps the offset in the file at the given path. A missing file
+// loads as offset 0, i.e. all pending updates.
+type FileOffsetStore string
+
+func (s FileOffsetStore) Load() (int, error) {
+	bs, err := ioutil.ReadFile(string(s))
+	if os.IsNotExist(err) {
+		return 0, nil
+	} else if err != nil {
+		return 0, err
+	}
+	return strconv.Atoi(strings.TrimSpace(string(bs)))
+}
+
+func (s FileOffsetStore) Save(offset int) error {
+	f, err := ioutil.TempFile(filepath.Dir(string(s)), filepath.Base(string(s))+".*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(f.Name())
+	if _, err := f.WriteString(strconv.Itoa(offset) + "\n"); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+	return os.Rename(f.Name(), string(s))
+}
+
+func (c *Connection) loadOffset() error {
+	if c.OffsetStore == nil {
+		return nil
+	}
+	offset, err := c.OffsetStore.Load()
+	if err != nil {
+		return err
+	}
+	c.mu.Lock()
+	c.offset, c.confirmed, c.saved = offset, offset, offset
+	c.mu.Unlock()
+	if c.OffsetFlushInterval > 0 {
+		c.goroutine(c.flushOffsets)
+	}
+	return nil
+}
+
+func (c *Connection) confirmOffset(offset int) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if offset > c.confirmed {
+		c.confirmed = offset
+	}
+}
+
+func (c *Connection) saveOffset() error {
+	if c.OffsetStore == nil || c.OffsetFlushInterval > 0 {
+		return nil
+	}
+	return c.flushOffset()
+}
+
+func (c *Connection) flushOffsets(ctx context.Context) {
+	ticker := time.NewTicker(c.OffsetFlushInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+		case <-ctx.Done():
+			if err := c.flushOffset(); err != nil {
+				c.logf("flush offset: %s", err)
+			}
+			return
+		}
+		if err := c.flushOffset(); err != nil {
+			c.logf("flush offset: %s", err)
+		}
+	}
+}
+
+func (c *Connection) flushOffset() error {
+	c.mu.Lock()
+	offset, saved := c.confirmed, c.saved
+	c.mu.Unlock()
+	if offset == saved {
+		return nil
+	}
+	if err := c.OffsetStore.Save(offset); err != nil {
+		return err
+	}
+	c.mu.Lock()
+	c.saved = offset
+	c.mu.Unlock()
+	return nil
+}
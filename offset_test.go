@@ -0,0 +1,47 @@
+package telegram_test
+
+import (
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/niklasfasching/telegram"
+	"github.com/niklasfasching/telegram/telegramtest"
+)
+
+func TestOffsetFlushInterval(t *testing.T) {
+	const n, interval = 200, 50 * time.Millisecond
+	s := telegramtest.NewServer()
+	defer s.Close()
+	store := &memoryOffsetStore{}
+	c := s.Connection()
+	c.OffsetStore, c.OffsetFlushInterval, c.Concurrency = store, interval, 4
+	handled := int32(0)
+	c.Handle("message", func(m telegram.Message) error {
+		atomic.AddInt32(&handled, 1)
+		return nil
+	})
+	start := time.Now()
+	go c.Start()
+	for i := 0; i < n; i++ {
+		s.InjectUpdate(`{"message": {"message_id": 1, "chat": {"id": 1}, "text": "hi"}}`)
+		if i%10 == 0 {
+			time.Sleep(time.Millisecond)
+		}
+	}
+	for deadline := time.Now().Add(5 * time.Second); atomic.LoadInt32(&handled) < n; time.Sleep(time.Millisecond) {
+		if time.Now().After(deadline) {
+			t.Fatalf("timed out with %d of %d updates handled", atomic.LoadInt32(&handled), n)
+		}
+	}
+	c.StopAndWait()
+	elapsed := time.Since(start)
+	store.Lock()
+	defer store.Unlock()
+	if store.offset != n+1 {
+		t.Errorf("expected offset %d, got %d", n+1, store.offset)
+	}
+	if max := int(elapsed/interval) + 2; store.saves > max {
+		t.Errorf("expected at most %d saves in %s, got %d", max, elapsed, store.saves)
+	}
+}
@@ -0,0 +1,122 @@
+package telegram
+
+import (
+	"context"
+	"time"
+)
+
+const slowModeCacheTTL = 10 * time.Minute
+
+const (
+	sendInterval      = time.Second / 30
+	chatSendInterval  = time.Second
+	groupSendInterval = 3 * time.Second
+)
+
+type slowMode struct {
+	delay     time.Duration
+	fetched   time.Time
+	nextSend  time.Time
+	populated bool
+}
+
+func (c *Connection) setFlood(retryAfter time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if until := time.Now().Add(retryAfter); until.After(c.floodUntil) {
+		c.floodUntil = until
+	}
+}
+
+func (c *Connection) waitFlood(ctx context.Context, method string) error {
+	if method == "getUpdates" {
+		return nil
+	}
+	c.mu.Lock()
+	wait := time.Until(c.floodUntil)
+	c.mu.Unlock()
+	if wait <= 0 {
+		return nil
+	}
+	return sleep(ctx, wait)
+}
+
+func (c *Connection) paceSlowMode(ctx context.Context, method string, params map[string]interface{}) error {
+	if !c.PaceSlowMode || !sendsMessage(method) {
+		return nil
+	}
+	chatID, ok := chatIDParam(params)
+	if !ok {
+		return nil
+	}
+	c.mu.Lock()
+	s := c.slowMode[chatID]
+	c.mu.Unlock()
+	if !s.populated || c.now().Sub(s.fetched) > slowModeCacheTTL {
+		chat, err := c.GetChat(chatID)
+		if err != nil {
+			return err
+		}
+		s.delay, s.fetched, s.populated = time.Duration(chat.SlowModeDelay)*time.Second, c.now(), true
+	}
+	// The slot is only reserved once it is due, so a send whose ctx is done
+	// while waiting doesn't delay the ones after it.
+	for {
+		c.mu.Lock()
+		now := c.now()
+		if existing, ok := c.slowMode[chatID]; ok {
+			s.nextSend = existing.nextSend
+		}
+		wait := s.nextSend.Sub(now)
+		if wait <= 0 {
+			s.nextSend = now.Add(s.delay)
+			if c.slowMode == nil {
+				c.slowMode = map[int64]slowMode{}
+			}
+			c.slowMode[chatID] = s
+			c.mu.Unlock()
+			return nil
+		}
+		c.mu.Unlock()
+		if err := c.sleep(ctx, wait); err != nil {
+			return err
+		}
+	}
+}
+
+func (c *Connection) throttle(ctx context.Context, method string, params map[string]interface{}) error {
+	if !c.Throttle && !isBroadcast(ctx) || !sendsMessage(method) {
+		return nil
+	}
+	c.mu.Lock()
+	now := time.Now()
+	next := now
+	if c.nextSend.After(next) {
+		next = c.nextSend
+	}
+	chatID, ok := chatIDParam(params)
+	if ok && c.nextChatSend[chatID].After(next) {
+		next = c.nextChatSend[chatID]
+	}
+	c.nextSend = next.Add(sendInterval)
+	if ok {
+		for id, t := range c.nextChatSend {
+			if t.Before(now) {
+				delete(c.nextChatSend, id)
+			}
+		}
+		if c.nextChatSend == nil {
+			c.nextChatSend = map[int64]time.Time{}
+		}
+		interval := chatSendInterval
+		if chatID < 0 {
+			interval = groupSendInterval
+		}
+		c.nextChatSend[chatID] = next.Add(interval)
+	}
+	c.mu.Unlock()
+	if next == now {
+		return nil
+	}
+	return sleep(ctx, next.Sub(now))
+}
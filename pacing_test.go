@@ -0,0 +1,72 @@
+package telegram_test
+
+import (
+	"context"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/niklasfasching/telegram"
+	"github.com/niklasfasching/telegram/telegramtest"
+)
+
+// fakeClock is a clock whose sleeps return immediately and advance it.
+type fakeClock struct {
+	sync.Mutex
+	t     time.Time
+	slept []time.Duration
+}
+
+func (c *fakeClock) now() time.Time {
+	c.Lock()
+	defer c.Unlock()
+	return c.t
+}
+
+func (c *fakeClock) sleep(ctx context.Context, d time.Duration) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	c.Lock()
+	defer c.Unlock()
+	c.t, c.slept = c.t.Add(d), append(c.slept, d)
+	return nil
+}
+
+func TestPaceSlowMode(t *testing.T) {
+	s := telegramtest.NewServer()
+	defer s.Close()
+	s.Respond("getChat", telegram.Chat{ID: -100, Type: "supergroup", SlowModeDelay: 1})
+	c := s.Connection()
+	c.PaceSlowMode = true
+	clock := &fakeClock{t: time.Unix(0, 0)}
+	telegram.SetClock(c, clock.now, clock.sleep)
+	send := func(ctx context.Context, chatID int64) error {
+		return c.CallContext(ctx, "sendMessage", map[string]interface{}{"chat_id": chatID, "text": "hi"}, nil)
+	}
+	if err := send(context.Background(), -100); err != nil {
+		t.Fatal(err)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if err := send(ctx, -100); err != context.Canceled {
+		t.Fatalf("expected the second send to wait for the slow mode delay, got %v", err)
+	}
+	if err := send(context.Background(), -101); err != nil {
+		t.Fatal(err)
+	} else if len(clock.slept) != 0 {
+		t.Errorf("expected other chats not to be paced, slept %v", clock.slept)
+	}
+	if err := send(context.Background(), -100); err != nil {
+		t.Fatal(err)
+	} else if want := []time.Duration{time.Second}; !reflect.DeepEqual(clock.slept, want) {
+		t.Errorf("expected the canceled send not to hold a slot, slept %v, want %v", clock.slept, want)
+	}
+	if texts := s.SentMessages(); len(texts) != 3 {
+		t.Errorf("expected 3 sends, got %v", texts)
+	}
+	if n := len(s.Calls("getChat")); n != 2 {
+		t.Errorf("expected getChat to be cached per chat, got %d calls", n)
+	}
+}
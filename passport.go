@@ -0,0 +1,88 @@
+package telegram
+
+import "encoding/json"
+
+type PassportElementError interface {
+	passportElementErrorSource() string
+}
+
+type PassportElementErrorDataField struct {
+	Type      string `json:"type"`
+	FieldName string `json:"field_name"`
+	DataHash  string `json:"data_hash"`
+	Message   string `json:"message"`
+}
+
+type PassportElementErrorFrontSide struct {
+	Type     string `json:"type"`
+	FileHash string `json:"file_hash"`
+	Message  string `json:"message"`
+}
+
+type PassportElementErrorReverseSide struct {
+	Type     string `json:"type"`
+	FileHash string `json:"file_hash"`
+	Message  string `json:"message"`
+}
+
+type PassportElementErrorSelfie struct {
+	Type     string `json:"type"`
+	FileHash string `json:"file_hash"`
+	Message  string `json:"message"`
+}
+
+type PassportElementErrorFile struct {
+	Type     string `json:"type"`
+	FileHash string `json:"file_hash"`
+	Message  string `json:"message"`
+}
+
+type PassportElementErrorFiles struct {
+	Type       string   `json:"type"`
+	FileHashes []string `json:"file_hashes"`
+	Message    string   `json:"message"`
+}
+
+type PassportElementErrorTranslationFile struct {
+	Type     string `json:"type"`
+	FileHash string `json:"file_hash"`
+	Message  string `json:"message"`
+}
+
+type PassportElementErrorTranslationFiles struct {
+	Type       string   `json:"type"`
+	FileHashes []string `json:"file_hashes"`
+	Message    string   `json:"message"`
+}
+
+type PassportElementErrorUnspecified struct {
+	Type        string `json:"type"`
+	ElementHash string `json:"element_hash"`
+	Message     string `json:"message"`
+}
+
+func (PassportElementErrorDataField) passportElementErrorSource() string   { return "data" }
+func (PassportElementErrorFrontSide) passportElementErrorSource() string   { return "front_side" }
+func (PassportElementErrorReverseSide) passportElementErrorSource() string { return "reverse_side" }
+func (PassportElementErrorSelfie) passportElementErrorSource() string      { return "selfie" }
+func (PassportElementErrorFile) passportElementErrorSource() string        { return "file" }
+func (PassportElementErrorFiles) passportElementErrorSource() string       { return "files" }
+func (PassportElementErrorTranslationFile) passportElementErrorSource() string {
+	return "translation_file"
+}
+func (PassportElementErrorTranslationFiles) passportElementErrorSource() string {
+	return "translation_files"
+}
+func (PassportElementErrorUnspecified) passportElementErrorSource() string { return "unspecified" }
+
+func (c *Connection) SetPassportDataErrors(userID int64, errors []PassportElementError) error {
+	es := make([]json.RawMessage, len(errors))
+	for i, e := range errors {
+		bs, err := marshalWithField(e, "source", e.passportElementErrorSource())
+		if err != nil {
+			return err
+		}
+		es[i] = bs
+	}
+	return c.Call("setPassportDataErrors", map[string]interface{}{"user_id": userID, "errors": es}, nil)
+}
@@ -0,0 +1,37 @@
+package telegram_test
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+
+	"github.com/niklasfasching/telegram"
+	"github.com/niklasfasching/telegram/telegramtest"
+)
+
+func TestSetPassportDataErrors(t *testing.T) {
+	s := telegramtest.NewServer()
+	defer s.Close()
+	errors := []telegram.PassportElementError{
+		telegram.PassportElementErrorDataField{Type: "passport", FieldName: "document_no", DataHash: "d", Message: "bad number"},
+		telegram.PassportElementErrorSelfie{Type: "passport", FileHash: "f", Message: "blurry"},
+		telegram.PassportElementErrorFiles{Type: "utility_bill", FileHashes: []string{"a", "b"}, Message: "outdated"},
+		telegram.PassportElementErrorUnspecified{Type: "address", ElementHash: "e", Message: "invalid"},
+	}
+	if err := s.Connection().SetPassportDataErrors(42, errors); err != nil {
+		t.Fatal(err)
+	}
+	call, got := s.Calls("setPassportDataErrors")[0], []map[string]interface{}{}
+	if err := json.Unmarshal([]byte(call.Params["errors"]), &got); err != nil {
+		t.Fatal(err)
+	}
+	want := []map[string]interface{}{
+		{"source": "data", "type": "passport", "field_name": "document_no", "data_hash": "d", "message": "bad number"},
+		{"source": "selfie", "type": "passport", "file_hash": "f", "message": "blurry"},
+		{"source": "files", "type": "utility_bill", "file_hashes": []interface{}{"a", "b"}, "message": "outdated"},
+		{"source": "unspecified", "type": "address", "element_hash": "e", "message": "invalid"},
+	}
+	if !reflect.DeepEqual(got, want) || call.Params["user_id"] != "42" {
+		t.Errorf("got errors %v for user %s, want %v", got, call.Params["user_id"], want)
+	}
+}
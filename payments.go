@@ -0,0 +1,107 @@
+package telegram
+
+// CurrencyStars is the currency of payments in Telegram Stars, which need no
+// payment provider token.
+const CurrencyStars = "XTR"
+
+// LabeledPrice is a portion of the price in the smallest units of the currency,
+// e.g. cents.
+type LabeledPrice struct {
+	Label  string `json:"label"`
+	Amount int    `json:"amount"`
+}
+
+type ShippingOption struct {
+	ID     string         `json:"id"`
+	Title  string         `json:"title"`
+	Prices []LabeledPrice `json:"prices"`
+}
+
+type ShippingAddress struct {
+	CountryCode string `json:"country_code"`
+	State       string `json:"state"`
+	City        string `json:"city"`
+	StreetLine1 string `json:"street_line1"`
+	StreetLine2 string `json:"street_line2"`
+	PostCode    string `json:"post_code"`
+}
+
+type OrderInfo struct {
+	Name            string           `json:"name"`
+	PhoneNumber     string           `json:"phone_number"`
+	Email           string           `json:"email"`
+	ShippingAddress *ShippingAddress `json:"shipping_address"`
+}
+
+type ShippingQuery struct {
+	ID              string          `json:"id"`
+	From            User            `json:"from"`
+	InvoicePayload  string          `json:"invoice_payload"`
+	ShippingAddress ShippingAddress `json:"shipping_address"`
+}
+
+type PreCheckoutQuery struct {
+	ID               string     `json:"id"`
+	From             User       `json:"from"`
+	Currency         string     `json:"currency"`
+	TotalAmount      int        `json:"total_amount"`
+	InvoicePayload   string     `json:"invoice_payload"`
+	ShippingOptionID string     `json:"shipping_option_id"`
+	OrderInfo        *OrderInfo `json:"order_info"`
+}
+
+func WithProviderToken(providerToken string) Option {
+	return func(p map[string]interface{}) { p["provider_token"] = providerToken }
+}
+
+// WithIsFlexible requests shipping_query updates to calculate the shipping
+// options once the user has entered their address.
+func WithIsFlexible(isFlexible bool) Option {
+	return func(p map[string]interface{}) { p["is_flexible"] = isFlexible }
+}
+
+func (c *Connection) SendInvoice(chatID int64, title, description, payload, currency string, prices []LabeledPrice, opts ...Option) (Message, error) {
+	m := Message{}
+	params := invoiceParams(title, description, payload, currency, prices)
+	params["chat_id"] = chatID
+	err := c.Call("sendInvoice", applyOptions(params, opts), &m)
+	return m, err
+}
+
+func (c *Connection) CreateInvoiceLink(title, description, payload, currency string, prices []LabeledPrice, opts ...Option) (string, error) {
+	link := ""
+	err := c.Call("createInvoiceLink", applyOptions(invoiceParams(title, description, payload, currency, prices), opts), &link)
+	return link, err
+}
+
+// AnswerShippingQuery offers options for the address of the query; a non-empty
+// errorMessage rejects the address instead.
+func (c *Connection) AnswerShippingQuery(queryID string, options []ShippingOption, errorMessage string) error {
+	params := map[string]interface{}{"shipping_query_id": queryID, "ok": errorMessage == ""}
+	if errorMessage != "" {
+		params["error_message"] = errorMessage
+	} else {
+		params["shipping_options"] = options
+	}
+	return c.Call("answerShippingQuery", params, nil)
+}
+
+// AnswerPreCheckoutQuery confirms the order or, with a non-empty errorMessage,
+// cancels it. It must be called within 10 seconds of receiving the query.
+func (c *Connection) AnswerPreCheckoutQuery(queryID string, errorMessage string) error {
+	params := map[string]interface{}{"pre_checkout_query_id": queryID, "ok": errorMessage == ""}
+	if errorMessage != "" {
+		params["error_message"] = errorMessage
+	}
+	return c.Call("answerPreCheckoutQuery", params, nil)
+}
+
+func invoiceParams(title, description, payload, currency string, prices []LabeledPrice) map[string]interface{} {
+	return map[string]interface{}{
+		"title":       title,
+		"description": description,
+		"payload":     payload,
+		"currency":    currency,
+		"prices":      prices,
+	}
+}
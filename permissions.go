@@ -0,0 +1,120 @@
+package telegram
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"time"
+)
+
+type ChatPermissions struct {
+	CanSendMessages       bool `json:"can_send_messages"`
+	CanSendAudios         bool `json:"can_send_audios"`
+	CanSendDocuments      bool `json:"can_send_documents"`
+	CanSendPhotos         bool `json:"can_send_photos"`
+	CanSendVideos         bool `json:"can_send_videos"`
+	CanSendVideoNotes     bool `json:"can_send_video_notes"`
+	CanSendVoiceNotes     bool `json:"can_send_voice_notes"`
+	CanSendPolls          bool `json:"can_send_polls"`
+	CanSendOtherMessages  bool `json:"can_send_other_messages"`
+	CanAddWebPagePreviews bool `json:"can_add_web_page_previews"`
+	CanChangeInfo         bool `json:"can_change_info"`
+	CanInviteUsers        bool `json:"can_invite_users"`
+	CanPinMessages        bool `json:"can_pin_messages"`
+	CanManageTopics       bool `json:"can_manage_topics"`
+}
+
+type ChatAdministratorRights struct {
+	IsAnonymous         bool `json:"is_anonymous"`
+	CanManageChat       bool `json:"can_manage_chat"`
+	CanDeleteMessages   bool `json:"can_delete_messages"`
+	CanManageVideoChats bool `json:"can_manage_video_chats"`
+	CanRestrictMembers  bool `json:"can_restrict_members"`
+	CanPromoteMembers   bool `json:"can_promote_members"`
+	CanChangeInfo       bool `json:"can_change_info"`
+	CanInviteUsers      bool `json:"can_invite_users"`
+	CanPostStories      bool `json:"can_post_stories"`
+	CanEditStories      bool `json:"can_edit_stories"`
+	CanDeleteStories    bool `json:"can_delete_stories"`
+	CanPostMessages     bool `json:"can_post_messages"`
+	CanEditMessages     bool `json:"can_edit_messages"`
+	CanPinMessages      bool `json:"can_pin_messages"`
+	CanManageTopics     bool `json:"can_manage_topics"`
+}
+
+func AllPermissions() ChatPermissions {
+	p := ChatPermissions{}
+	setFlags(&p, true, nil)
+	return p
+}
+
+func NoPermissions() ChatPermissions { return ChatPermissions{} }
+
+func (p ChatPermissions) Allow(names ...string) ChatPermissions {
+	setFlags(&p, true, names)
+	return p
+}
+
+func (p ChatPermissions) Deny(names ...string) ChatPermissions {
+	setFlags(&p, false, names)
+	return p
+}
+
+func AllAdminRights() ChatAdministratorRights {
+	r := ChatAdministratorRights{}
+	setFlags(&r, true, nil)
+	r.IsAnonymous = false
+	return r
+}
+
+func NoAdminRights() ChatAdministratorRights { return ChatAdministratorRights{} }
+
+func (r ChatAdministratorRights) Allow(names ...string) ChatAdministratorRights {
+	setFlags(&r, true, names)
+	return r
+}
+
+func (r ChatAdministratorRights) Deny(names ...string) ChatAdministratorRights {
+	setFlags(&r, false, names)
+	return r
+}
+
+// UntilTime is when a restriction or ban ends; the zero time means never.
+func (m ChatMember) UntilTime() time.Time { return unixTime(m.UntilDate) }
+
+func (c *Connection) RestrictChatMember(chatID, userID int64, permissions ChatPermissions, opts ...Option) error {
+	return c.Call("restrictChatMember", applyOptions(map[string]interface{}{
+		"chat_id":     chatID,
+		"user_id":     userID,
+		"permissions": permissions,
+	}, opts), nil)
+}
+
+func (c *Connection) PromoteChatMember(chatID, userID int64, rights ChatAdministratorRights) error {
+	params, err := toMap(rights)
+	if err != nil {
+		return err
+	}
+	params["chat_id"], params["user_id"] = chatID, userID
+	return c.Call("promoteChatMember", params, nil)
+}
+
+func setFlags(v interface{}, value bool, names []string) {
+	rv := reflect.ValueOf(v).Elem()
+	fields := map[string]reflect.Value{}
+	for i := 0; i < rv.NumField(); i++ {
+		fields[strings.Split(rv.Type().Field(i).Tag.Get("json"), ",")[0]] = rv.Field(i)
+	}
+	if names == nil {
+		for _, f := range fields {
+			f.SetBool(value)
+		}
+	}
+	for _, name := range names {
+		f, ok := fields[name]
+		if !ok {
+			panic(fmt.Errorf("unknown %s flag %s", rv.Type().Name(), name))
+		}
+		f.SetBool(value)
+	}
+}
@@ -0,0 +1,76 @@
+package telegram_test
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/niklasfasching/telegram"
+	"github.com/niklasfasching/telegram/telegramtest"
+)
+
+func flags(t *testing.T, v interface{}) map[string]bool {
+	t.Helper()
+	bs, err := json.Marshal(v)
+	if err != nil {
+		t.Fatal(err)
+	}
+	m := map[string]bool{}
+	if err := json.Unmarshal(bs, &m); err != nil {
+		t.Fatal(err)
+	}
+	return m
+}
+
+func TestPermissions(t *testing.T) {
+	all, none := flags(t, telegram.AllPermissions()), flags(t, telegram.NoPermissions())
+	if len(all) != 14 || len(none) != 14 {
+		t.Fatalf("expected all 14 flags to be encoded, got %v and %v", all, none)
+	}
+	for k := range all {
+		if !all[k] || none[k] {
+			t.Errorf("%s: got %v for all and %v for none", k, all[k], none[k])
+		}
+	}
+	mixed := flags(t, telegram.NoPermissions().Allow("can_send_messages", "can_send_photos").Deny("can_send_photos"))
+	for k, v := range mixed {
+		if v != (k == "can_send_messages") {
+			t.Errorf("mixed %s: got %v", k, v)
+		}
+	}
+
+	rights := flags(t, telegram.AllAdminRights())
+	if rights["is_anonymous"] || !rights["can_manage_chat"] || !rights["can_promote_members"] {
+		t.Errorf("unexpected admin rights %v", rights)
+	}
+	rights = flags(t, telegram.AllAdminRights().Deny("can_promote_members").Allow("is_anonymous"))
+	if !rights["is_anonymous"] || rights["can_promote_members"] || !rights["can_delete_messages"] {
+		t.Errorf("unexpected admin rights %v", rights)
+	}
+
+	defer func() {
+		if recover() == nil {
+			t.Error("expected an unknown flag to panic")
+		}
+	}()
+	telegram.NoPermissions().Allow("can_fly")
+}
+
+func TestPromoteChatMember(t *testing.T) {
+	s := telegramtest.NewServer()
+	defer s.Close()
+	c := s.Connection()
+	if err := c.PromoteChatMember(-100, 42, telegram.NoAdminRights().Allow("can_pin_messages")); err != nil {
+		t.Fatal(err)
+	}
+	if err := c.RestrictChatMember(-100, 42, telegram.NoPermissions()); err != nil {
+		t.Fatal(err)
+	}
+	params := s.Calls("promoteChatMember")[0].Params
+	if params["can_pin_messages"] != "true" || params["can_delete_messages"] != "false" || params["user_id"] != "42" {
+		t.Errorf("expected explicit flags, got %v", params)
+	}
+	permissions := map[string]bool{}
+	if err := json.Unmarshal([]byte(s.Calls("restrictChatMember")[0].Params["permissions"]), &permissions); err != nil || len(permissions) != 14 {
+		t.Errorf("expected explicit false permissions, got %v: %v", permissions, err)
+	}
+}
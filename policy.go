@@ -0,0 +1,89 @@
+package telegram
+
+import (
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+)
+
+func (c *Connection) protectContent(method string, params map[string]interface{}) {
+	if _, ok := params["protect_content"]; ok || !sendsMessage(method) {
+		return
+	}
+	protect := c.ProtectContent
+	if chatID, ok := chatIDParam(params); ok {
+		if v, ok := c.ProtectContentByChat[chatID]; ok {
+			protect = v
+		}
+	}
+	if protect {
+		params["protect_content"] = true
+	}
+}
+
+func sendsMessage(method string) bool {
+	switch method {
+	case "sendChatAction":
+		return false
+	case "forwardMessage", "forwardMessages", "copyMessage", "copyMessages":
+		return true
+	}
+	return strings.HasPrefix(method, "send")
+}
+
+func chatIDParam(params map[string]interface{}) (int64, bool) {
+	id, err := strconv.ParseInt(fmt.Sprint(params["chat_id"]), 10, 64)
+	return id, err == nil
+}
+
+func validateMessageEffect(method string, params map[string]interface{}) error {
+	if params["message_effect_id"] == nil {
+		return nil
+	}
+	if id, ok := chatIDParam(params); ok && id < 0 || strings.HasPrefix(fmt.Sprint(params["chat_id"]), "@") {
+		return fmt.Errorf("%s: message_effect_id is only allowed in private chats, not in %v", method, params["chat_id"])
+	}
+	return nil
+}
+
+func hasReader(params map[string]interface{}) bool {
+	for _, v := range params {
+		if _, ok := v.(io.Reader); ok {
+			return true
+		}
+	}
+	return false
+}
+
+func (c *Connection) duplicateSend(method string, params map[string]interface{}) bool {
+	if c.SendDebounce <= 0 || !sendsMessage(method) {
+		return false
+	}
+	if hasReader(params) {
+		return false
+	}
+	bs, err := json.Marshal(params)
+	if err != nil {
+		return false
+	}
+	key, now := fmt.Sprintf("%s:%x", method, sha256.Sum256(bs)), time.Now()
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for k, t := range c.recentSends {
+		if now.Sub(t) > c.SendDebounce {
+			delete(c.recentSends, k)
+		}
+	}
+	if _, ok := c.recentSends[key]; ok {
+		return true
+	}
+	if c.recentSends == nil {
+		c.recentSends = map[string]time.Time{}
+	}
+	c.recentSends[key] = now
+	return false
+}
@@ -0,0 +1,77 @@
+package telegram_test
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/niklasfasching/telegram"
+	"github.com/niklasfasching/telegram/telegramtest"
+)
+
+func TestSendDebounce(t *testing.T) {
+	s := telegramtest.NewServer()
+	defer s.Close()
+	c := s.Connection()
+	c.SendDebounce = time.Minute
+	if _, err := c.SendMessage(1, "hi"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := c.SendMessage(1, "hi"); !errors.Is(err, telegram.ErrDuplicate) {
+		t.Errorf("expected ErrDuplicate, got %v", err)
+	}
+	if _, err := c.SendMessage(1, "ho"); err != nil {
+		t.Fatal(err)
+	} else if _, err := c.SendMessage(2, "hi"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := c.SendAndPin(1, "hi"); !errors.Is(err, telegram.ErrDuplicate) {
+		t.Errorf("expected SendAndPin to fail with ErrDuplicate, got %v", err)
+	}
+	if texts, pins := s.SentMessages(), s.Calls("pinChatMessage"); len(texts) != 3 || len(pins) != 0 {
+		t.Errorf("expected 3 sends and no pins, got %v and %v", texts, pins)
+	}
+}
+
+func TestProtectContentByChat(t *testing.T) {
+	s := telegramtest.NewServer()
+	defer s.Close()
+	c := s.Connection()
+	c.ProtectContent, c.ProtectContentByChat = true, map[int64]bool{-100: false}
+	for _, chatID := range []int64{1, -100} {
+		if _, err := c.SendMessage(chatID, "hi"); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := c.Call("sendPhoto", map[string]interface{}{"chat_id": -100, "photo": "a", "protect_content": true}, nil); err != nil {
+		t.Fatal(err)
+	}
+	calls := append(s.Calls("sendMessage"), s.Calls("sendPhoto")...)
+	for i, want := range []string{"true", "", "true"} {
+		if got := calls[i].Params["protect_content"]; got != want {
+			t.Errorf("chat %s: got protect_content %q, want %q", calls[i].Params["chat_id"], got, want)
+		}
+	}
+}
+
+func TestMessageEffectPrivateOnly(t *testing.T) {
+	s := telegramtest.NewServer()
+	defer s.Close()
+	c := s.Connection()
+	if _, err := c.SendMessage(1, "hi", telegram.WithMessageEffect("5104841245755180586")); err != nil {
+		t.Fatal(err)
+	} else if call := s.Calls("sendMessage")[0]; call.Params["message_effect_id"] != "5104841245755180586" {
+		t.Errorf("expected the effect to be sent to a private chat, got %v", call.Params)
+	}
+	if _, err := c.SendMessage(-100, "hi", telegram.WithMessageEffect("5104841245755180586")); err == nil || !strings.Contains(err.Error(), "only allowed in private chats") {
+		t.Errorf("expected the effect to be rejected for a group, got %v", err)
+	}
+	err := c.Call("sendMessage", map[string]interface{}{"chat_id": "@channel", "text": "hi", "message_effect_id": "1"}, nil)
+	if err == nil || !strings.Contains(err.Error(), "only allowed in private chats") {
+		t.Errorf("expected the effect to be rejected for a channel username, got %v", err)
+	}
+	if n := len(s.SentMessages()); n != 1 {
+		t.Errorf("expected rejected sends not to reach the server, got %d messages", n)
+	}
+}
@@ -0,0 +1,75 @@
+package telegram
+
+import "encoding/json"
+
+const maxTrackedPolls = 1024
+
+type InputPollOption struct {
+	Text string `json:"text"`
+}
+
+func (c *Connection) SendPoll(chatID int64, question string, options []string, opts ...Option) (Message, error) {
+	m, pollOptions := Message{}, make([]InputPollOption, len(options))
+	for i, o := range options {
+		pollOptions[i] = InputPollOption{Text: o}
+	}
+	err := c.Call("sendPoll", applyOptions(map[string]interface{}{
+		"chat_id":  chatID,
+		"question": question,
+		"options":  pollOptions,
+	}, opts), &m)
+	return m, err
+}
+
+// WithQuiz turns a poll into a quiz with exactly one correct option.
+func WithQuiz(correctOptionID int) Option {
+	return func(p map[string]interface{}) { p["type"], p["correct_option_id"] = "quiz", correctOptionID }
+}
+
+// WithExplanation is shown when a wrong quiz answer is chosen.
+func WithExplanation(explanation string) Option {
+	return func(p map[string]interface{}) { p["explanation"] = explanation }
+}
+
+func WithIsAnonymous(isAnonymous bool) Option {
+	return func(p map[string]interface{}) { p["is_anonymous"] = isAnonymous }
+}
+
+func WithAllowsMultipleAnswers(allowsMultipleAnswers bool) Option {
+	return func(p map[string]interface{}) { p["allows_multiple_answers"] = allowsMultipleAnswers }
+}
+
+func WithOpenPeriod(seconds int) Option {
+	return func(p map[string]interface{}) { p["open_period"] = seconds }
+}
+
+// PollMessage returns the message that carried the poll, for polls sent while
+// TrackPolls was set. Anonymous polls only produce poll updates, which don't say
+// what message they belong to.
+func (c *Connection) PollMessage(pollID string) (*Message, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	m, ok := c.polls[pollID]
+	return m, ok
+}
+
+func (c *Connection) trackPoll(method string, result json.RawMessage) {
+	if !c.TrackPolls || method != "sendPoll" {
+		return
+	}
+	m := &Message{}
+	if err := json.Unmarshal(result, m); err != nil || m.Poll == nil {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.polls == nil {
+		c.polls = map[string]*Message{}
+	}
+	c.polls[m.Poll.ID] = m
+	c.pollOrder = append(c.pollOrder, m.Poll.ID)
+	if len(c.pollOrder) > maxTrackedPolls {
+		delete(c.polls, c.pollOrder[0])
+		c.pollOrder = c.pollOrder[1:]
+	}
+}
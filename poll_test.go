@@ -0,0 +1,30 @@
+package telegram_test
+
+import (
+	"testing"
+
+	"github.com/niklasfasching/telegram"
+	"github.com/niklasfasching/telegram/telegramtest"
+)
+
+func TestPollMessage(t *testing.T) {
+	s := telegramtest.NewServer()
+	defer s.Close()
+	s.Respond("sendPoll", telegram.Message{ID: 7, Chat: telegram.Chat{ID: 1}, Poll: &telegram.Poll{ID: "poll", Question: "?"}})
+	c := s.Connection()
+	if _, err := c.SendPoll(1, "?", []string{"a", "b"}, telegram.WithIsAnonymous(true)); err != nil {
+		t.Fatal(err)
+	} else if _, ok := c.PollMessage("poll"); ok {
+		t.Fatal("expected polls not to be tracked without TrackPolls")
+	}
+	c.TrackPolls = true
+	if _, err := c.SendPoll(1, "?", []string{"a", "b"}, telegram.WithIsAnonymous(true)); err != nil {
+		t.Fatal(err)
+	}
+	if m, ok := c.PollMessage("poll"); !ok || m.ID != 7 || m.Chat.ID != 1 {
+		t.Errorf("expected the poll to resolve to its message, got %v %v", m, ok)
+	}
+	if _, ok := c.PollMessage("other"); ok {
+		t.Error("expected unknown polls not to resolve")
+	}
+}
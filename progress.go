@@ -0,0 +1,70 @@
+package telegram
+
+import (
+	"sync"
+	"time"
+)
+
+type ProgressReporter struct {
+	c        *Connection
+	message  Message
+	interval time.Duration
+
+	mu       sync.Mutex
+	text     string
+	pending  string
+	lastEdit time.Time
+	timer    *time.Timer
+}
+
+func (c *Connection) NewProgressReporter(status Message, interval time.Duration) *ProgressReporter {
+	if interval <= 0 {
+		interval = time.Second
+	}
+	return &ProgressReporter{c: c, message: status, interval: interval, text: status.Text}
+}
+
+func (p *ProgressReporter) Update(text string) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.pending = text
+	if wait := p.interval - time.Since(p.lastEdit); wait > 0 {
+		if p.timer == nil {
+			p.timer = time.AfterFunc(wait, p.flush)
+		}
+		return nil
+	}
+	return p.edit()
+}
+
+func (p *ProgressReporter) Done(text string) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.timer != nil {
+		p.timer.Stop()
+		p.timer = nil
+	}
+	p.pending = text
+	return p.edit()
+}
+
+func (p *ProgressReporter) flush() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.timer = nil
+	if err := p.edit(); err != nil {
+		p.c.logf("progress: %s", err)
+	}
+}
+
+func (p *ProgressReporter) edit() error {
+	if p.pending == p.text {
+		return nil
+	}
+	m, err := p.c.EditMessageText(p.message.Chat.ID, p.message.ID, p.pending)
+	if err != nil {
+		return err
+	}
+	p.message, p.text, p.lastEdit = m, p.pending, time.Now()
+	return nil
+}
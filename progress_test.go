@@ -0,0 +1,49 @@
+package telegram_test
+
+import (
+	"encoding/json"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/niklasfasching/telegram"
+	"github.com/niklasfasching/telegram/telegramtest"
+)
+
+func TestProgressReporter(t *testing.T) {
+	const interval = 50 * time.Millisecond
+	s := telegramtest.NewServer()
+	defer s.Close()
+	s.RespondFunc("editMessageText", func(call telegramtest.Call) interface{} {
+		return json.RawMessage(fmt.Sprintf(`{"message_id": %s, "chat": {"id": %s}, "text": %q}`, call.Params["message_id"], call.Params["chat_id"], call.Params["text"]))
+	})
+	c := s.Connection()
+	p := c.NewProgressReporter(telegram.Message{ID: 1, Chat: telegram.Chat{ID: 1}, Text: "0%"}, interval)
+	start := time.Now()
+	for i := 0; i <= 100; i++ {
+		if err := p.Update(fmt.Sprintf("%d%%", i)); err != nil {
+			t.Fatal(err)
+		}
+		time.Sleep(time.Millisecond)
+	}
+	time.Sleep(2 * interval)
+	edits := s.Calls("editMessageText")
+	if max := int(time.Since(start)/interval) + 1; len(edits) > max || len(edits) < 2 {
+		t.Errorf("expected 2-%d edits, got %d", max, len(edits))
+	}
+	if last := edits[len(edits)-1].Params["text"]; last != "100%" {
+		t.Errorf("expected the latest progress to be flushed, got %s", last)
+	}
+	if err := p.Update("100%"); err != nil {
+		t.Fatal(err)
+	}
+	if err := p.Done("done"); err != nil {
+		t.Fatal(err)
+	}
+	if err := p.Done("done"); err != nil {
+		t.Fatal(err)
+	}
+	if calls := s.Calls("editMessageText"); len(calls) != len(edits)+1 || calls[len(calls)-1].Params["text"] != "done" {
+		t.Errorf("expected a single final edit, got %v", calls[len(edits):])
+	}
+}
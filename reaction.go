@@ -0,0 +1,49 @@
+package telegram
+
+// MessageReactionUpdated is sent for reactions of users in chats where the bot
+// is an administrator. Anonymous reactions have ActorChat set instead of User.
+type MessageReactionUpdated struct {
+	Chat        Chat           `json:"chat"`
+	MessageID   int            `json:"message_id"`
+	User        *User          `json:"user"`
+	ActorChat   *Chat          `json:"actor_chat"`
+	Date        int            `json:"date"`
+	OldReaction []ReactionType `json:"old_reaction"`
+	NewReaction []ReactionType `json:"new_reaction"`
+}
+
+// MessageReactionCountUpdated is sent, with a delay, for anonymous reactions.
+type MessageReactionCountUpdated struct {
+	Chat      Chat            `json:"chat"`
+	MessageID int             `json:"message_id"`
+	Date      int             `json:"date"`
+	Reactions []ReactionCount `json:"reactions"`
+}
+
+type ReactionCount struct {
+	Type       ReactionType `json:"type"`
+	TotalCount int          `json:"total_count"`
+}
+
+func EmojiReaction(emoji string) ReactionType { return ReactionType{Type: "emoji", Emoji: emoji} }
+
+func CustomEmojiReaction(customEmojiID string) ReactionType {
+	return ReactionType{Type: "custom_emoji", CustomEmojiID: customEmojiID}
+}
+
+// SetMessageReaction replaces the bot's reactions on a message; no reactions
+// remove them.
+func (c *Connection) SetMessageReaction(chatID int64, messageID int, reactions []ReactionType, opts ...Option) error {
+	if reactions == nil {
+		reactions = []ReactionType{}
+	}
+	return c.Call("setMessageReaction", applyOptions(map[string]interface{}{
+		"chat_id":    chatID,
+		"message_id": messageID,
+		"reaction":   reactions,
+	}, opts), nil)
+}
+
+func WithIsBig(isBig bool) Option {
+	return func(p map[string]interface{}) { p["is_big"] = isBig }
+}
@@ -0,0 +1,44 @@
+package telegram_test
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+
+	"github.com/niklasfasching/telegram"
+	"github.com/niklasfasching/telegram/telegramtest"
+)
+
+func TestAvailableReactions(t *testing.T) {
+	s := telegramtest.NewServer()
+	defer s.Close()
+	c := s.Connection()
+	s.Respond("getChat", json.RawMessage(`{"id": -100, "type": "channel", "available_reactions": [{"type": "emoji", "emoji": "👍"}, {"type": "custom_emoji", "custom_emoji_id": "5"}]}`))
+	chat, err := c.GetChat(-100)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []telegram.ReactionType{telegram.EmojiReaction("👍"), telegram.CustomEmojiReaction("5")}
+	if !reflect.DeepEqual(chat.AvailableReactions, want) {
+		t.Errorf("got reactions %#v, want %#v", chat.AvailableReactions, want)
+	}
+}
+
+func TestSetMessageReaction(t *testing.T) {
+	s := telegramtest.NewServer()
+	defer s.Close()
+	c := s.Connection()
+	if err := c.SetMessageReaction(1, 2, []telegram.ReactionType{telegram.EmojiReaction("👍"), telegram.CustomEmojiReaction("5")}, telegram.WithIsBig(true)); err != nil {
+		t.Fatal(err)
+	}
+	if err := c.SetMessageReaction(1, 2, nil); err != nil {
+		t.Fatal(err)
+	}
+	calls := s.Calls("setMessageReaction")
+	if got, want := calls[0].Params["reaction"], `[{"type":"emoji","emoji":"👍"},{"type":"custom_emoji","custom_emoji_id":"5"}]`; got != want || calls[0].Params["is_big"] != "true" {
+		t.Errorf("got reaction %s, want %s", got, want)
+	}
+	if got := calls[1].Params["reaction"]; got != "[]" {
+		t.Errorf("expected no reactions to remove them, got %s", got)
+	}
+}
@@ -0,0 +1,118 @@
+package telegram
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/rand"
+	"net/url"
+	"sync"
+	"time"
+)
+
+const (
+	minBackoff = 1 * time.Second
+	maxBackoff = 30 * time.Second
+)
+
+func (c *Connection) retry(ctx context.Context, name string, f func() error) error {
+	for backoff := minBackoff; ; backoff *= 2 {
+		err := f()
+		if err == nil || ctx.Err() != nil || !IsRetryable(err) {
+			return err
+		}
+		if backoff > maxBackoff {
+			backoff = maxBackoff
+		}
+		d := backoff/2 + time.Duration(rand.Int63n(int64(backoff/2)+1))
+		if !spendRetryBudget(ctx, c.RetryBudget, d) {
+			return err
+		}
+		if c.OnError != nil {
+			c.OnError(fmt.Errorf("%s failed, retrying in %s: %w", name, d.Round(time.Millisecond), err))
+		} else {
+			c.logf("%s failed, retrying in %s: %s", name, d.Round(time.Millisecond), err)
+		}
+		if err := c.sleep(ctx, d); err != nil {
+			return err
+		}
+	}
+}
+
+// IsRetryable reports whether err is transient: network errors, timeouts, 429
+// and 5xx. Cancellation is not. Request timeouts are reported as expired
+// deadlines, so callers whose own ctx is done should give up regardless.
+func IsRetryable(err error) bool {
+	apiErr, urlErr, syntaxErr := &APIError{}, &url.Error{}, &json.SyntaxError{}
+	switch {
+	case errors.Is(err, context.Canceled):
+		return false
+	case errors.Is(err, context.DeadlineExceeded):
+		return true
+	case errors.As(err, &apiErr):
+		return apiErr.ErrorCode == 429 || apiErr.ErrorCode >= 500
+	case errors.As(err, &urlErr), errors.As(err, &syntaxErr):
+		return true
+	}
+	return false
+}
+
+func isNetworkError(err error) bool {
+	urlErr := &url.Error{}
+	return errors.As(err, &urlErr) && !errors.Is(err, context.Canceled)
+}
+
+type retryBudgetKey struct{}
+
+type retryBudget struct {
+	sync.Mutex
+	spent time.Duration
+}
+
+func withRetryBudget(ctx context.Context) context.Context {
+	return context.WithValue(ctx, retryBudgetKey{}, &retryBudget{})
+}
+
+// spendRetryBudget reports whether the budget of ctx allows waiting d more.
+// Calls made outside of update handling, other than getMe in Start, have no
+// budget.
+func spendRetryBudget(ctx context.Context, max, d time.Duration) bool {
+	b, ok := ctx.Value(retryBudgetKey{}).(*retryBudget)
+	if !ok || max <= 0 {
+		return true
+	}
+	b.Lock()
+	defer b.Unlock()
+	if b.spent+d > max {
+		return false
+	}
+	b.spent += d
+	return true
+}
+
+// now and sleep are time.Now and sleep unless a test swapped the clock of c.
+func (c *Connection) now() time.Time {
+	if c.clock != nil {
+		return c.clock()
+	}
+	return time.Now()
+}
+
+func (c *Connection) sleep(ctx context.Context, d time.Duration) error {
+	if c.sleeper != nil {
+		return c.sleeper(ctx, d)
+	}
+	return sleep(ctx, d)
+}
+
+func sleep(ctx context.Context, d time.Duration) error {
+	t := time.NewTimer(d)
+	defer t.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-t.C:
+		return nil
+	}
+}
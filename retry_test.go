@@ -0,0 +1,290 @@
+package telegram_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/niklasfasching/telegram"
+	"github.com/niklasfasching/telegram/telegramtest"
+)
+
+func TestIsRetryable(t *testing.T) {
+	syntaxErr := json.Unmarshal([]byte(`{"ok":`), &struct{}{})
+	tests := []struct {
+		name string
+		err  error
+		want bool
+	}{
+		{"rate limit", &telegram.APIError{ErrorCode: 429}, true},
+		{"server error", &telegram.APIError{ErrorCode: 502}, true},
+		{"bad request", &telegram.APIError{ErrorCode: 400}, false},
+		{"forbidden", &telegram.APIError{ErrorCode: 403}, false},
+		{"network", &url.Error{Op: "Post", URL: "x", Err: errors.New("connection reset by peer")}, true},
+		{"timeout", &url.Error{Op: "Post", URL: "x", Err: fmt.Errorf("client timeout: %w", context.DeadlineExceeded)}, true},
+		{"deadline", context.DeadlineExceeded, true},
+		{"truncated response", syntaxErr, true},
+		{"canceled", context.Canceled, false},
+		{"canceled request", &url.Error{Op: "Post", URL: "x", Err: context.Canceled}, false},
+		{"wrapped api error", fmt.Errorf("getUpdates: %w", &telegram.APIError{ErrorCode: 500}), true},
+		{"other", errors.New("boom"), false},
+	}
+	for _, test := range tests {
+		if got := telegram.IsRetryable(test.err); got != test.want {
+			t.Errorf("%s: IsRetryable(%v) = %v, want %v", test.name, test.err, got, test.want)
+		}
+	}
+}
+
+func TestIsRetryableClientTimeout(t *testing.T) {
+	done := make(chan struct{})
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-done:
+		case <-r.Context().Done():
+		}
+	}))
+	defer s.Close()
+	defer close(done)
+	c := &telegram.Connection{Token: telegramtest.Token, BaseURL: s.URL, MaxRetries: -1, Client: &http.Client{Timeout: 50 * time.Millisecond}}
+	err := c.Call("sendMessage", map[string]interface{}{"chat_id": 1, "text": "hi"}, nil)
+	if err == nil || !telegram.IsRetryable(err) {
+		t.Fatalf("client timeout %v should be retryable", err)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if err := c.CallContext(ctx, "sendMessage", map[string]interface{}{"chat_id": 1, "text": "hi"}, nil); err == nil || telegram.IsRetryable(err) {
+		t.Fatalf("cancellation %v should not be retryable", err)
+	}
+}
+
+// flakyTransport fails the first fails requests of method with a network
+// error and counts all attempts.
+type flakyTransport struct {
+	sync.Mutex
+	method          string
+	fails, attempts int
+}
+
+func (t *flakyTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if strings.HasSuffix(req.URL.Path, "/"+t.method) {
+		t.Lock()
+		t.attempts++
+		fail := t.fails < 0 || t.attempts <= t.fails
+		t.Unlock()
+		if fail {
+			if req.Body != nil {
+				io.Copy(io.Discard, req.Body)
+				req.Body.Close()
+			}
+			return nil, errors.New("connection reset by peer")
+		}
+	}
+	return http.DefaultTransport.RoundTrip(req)
+}
+
+func (t *flakyTransport) count() int {
+	t.Lock()
+	defer t.Unlock()
+	return t.attempts
+}
+
+func TestCallRetriesNetworkErrors(t *testing.T) {
+	s := telegramtest.NewServer()
+	defer s.Close()
+	transport := &flakyTransport{method: "sendMessage", fails: 2}
+	c := s.Connection()
+	c.Client, c.RetryDelay = &http.Client{Transport: transport}, time.Millisecond
+	if _, err := c.SendMessage(1, "hi"); err != nil {
+		t.Fatal(err)
+	}
+	if n := transport.count(); n != 3 {
+		t.Errorf("expected 3 attempts, got %d", n)
+	}
+	if texts := s.SentMessages(); len(texts) != 1 || texts[0] != "hi" {
+		t.Errorf("expected a single sent message, got %v", texts)
+	}
+
+	transport = &flakyTransport{method: "sendMessage", fails: 5}
+	c.Client, c.MaxRetries = &http.Client{Transport: transport}, 2
+	if _, err := c.SendMessage(1, "hi"); err == nil {
+		t.Fatal("expected an error after exhausting MaxRetries")
+	} else if n := transport.count(); n != 3 {
+		t.Errorf("expected 3 attempts, got %d", n)
+	}
+}
+
+func TestCallDoesNotRetryConsumedReaders(t *testing.T) {
+	s := telegramtest.NewServer()
+	defer s.Close()
+	transport := &flakyTransport{method: "sendDocument", fails: 1}
+	c := s.Connection()
+	c.Client, c.RetryDelay = &http.Client{Transport: transport}, time.Millisecond
+	reader := struct{ io.Reader }{strings.NewReader("data")}
+	if err := c.Call("sendDocument", map[string]interface{}{"chat_id": 1, "document": reader}, nil); err == nil {
+		t.Fatal("expected the network error")
+	} else if n := transport.count(); n != 1 {
+		t.Errorf("expected 1 attempt for an unseekable upload, got %d", n)
+	}
+
+	transport = &flakyTransport{method: "sendDocument", fails: 1}
+	c.Client = &http.Client{Transport: transport}
+	if err := c.Call("sendDocument", map[string]interface{}{"chat_id": 1, "document": strings.NewReader("data")}, nil); err != nil {
+		t.Fatal(err)
+	} else if files := s.Calls("sendDocument")[0].Files; string(files["document"]) != "data" {
+		t.Errorf("expected the retried upload to be complete, got %q", files["document"])
+	}
+}
+
+func TestRetryBudget(t *testing.T) {
+	s := telegramtest.NewServer()
+	defer s.Close()
+	transport := &flakyTransport{method: "getUpdates", fails: -1}
+	c := s.Connection()
+	c.Client, c.RetryBudget = &http.Client{Transport: transport}, 1400*time.Millisecond
+	errc := make(chan error, 1)
+	go func() { errc <- c.Start() }()
+	select {
+	case err := <-errc:
+		if !telegram.IsRetryable(err) {
+			t.Fatalf("expected the network error, got %v", err)
+		}
+	case <-time.After(5 * time.Second):
+		c.StopAndWait()
+		t.Fatal("expected the budget to end the getUpdates retries")
+	}
+	// The first backoff is at most 1s and the second at least 1s, so the budget
+	// allows one retry.
+	if n := transport.count(); n != 2 {
+		t.Errorf("expected 2 getUpdates attempts, got %d", n)
+	}
+
+	transport = &flakyTransport{method: "sendMessage", fails: -1}
+	c = s.Connection()
+	c.Client = &http.Client{Transport: transport}
+	c.MaxRetries, c.RetryDelay, c.RetryBudget = 10, 20*time.Millisecond, 50*time.Millisecond
+	handled := make(chan struct{}, 2)
+	c.Handle("message", func(ctx context.Context, m telegram.Message) error {
+		defer func() { handled <- struct{}{} }()
+		return c.CallContext(ctx, "sendMessage", map[string]interface{}{"chat_id": m.Chat.ID, "text": "hi"}, nil)
+	})
+	c.OnError = func(error) {}
+	s.InjectUpdate(`{"message": {"message_id": 1, "chat": {"id": 1}, "text": "a"}}`)
+	s.InjectUpdate(`{"message": {"message_id": 2, "chat": {"id": 1}, "text": "b"}}`)
+	go c.Start()
+	defer c.StopAndWait()
+	for i := 0; i < 2; i++ {
+		select {
+		case <-handled:
+		case <-time.After(5 * time.Second):
+			t.Fatal("timed out waiting for the handlers")
+		}
+	}
+	// Without the budget, each update would be attempted 1+MaxRetries times.
+	// Every retry waits at least RetryDelay/2, so the budget of the cycle allows
+	// at most 5 retries for both updates together.
+	if n := transport.count(); n < 3 || n > 7 {
+		t.Errorf("expected 3-7 attempts within the budget, got %d", n)
+	}
+
+	h := &telegramtest.Harness{Connection: c}
+	before := transport.count()
+	h.Inject(`{"update_id": 10, "message": {"message_id": 3, "chat": {"id": 1}, "text": "c"}}`)
+	if n := transport.count() - before; n < 2 {
+		t.Errorf("expected a fresh budget per dispatched update, got %d attempts", n)
+	}
+}
+
+func TestStartRetriesGetMe(t *testing.T) {
+	s := telegramtest.NewServer()
+	defer s.Close()
+	transport := &flakyTransport{method: "getMe", fails: 2}
+	c := s.Connection()
+	c.Client, c.RetryDelay = &http.Client{Transport: transport}, time.Millisecond
+	go c.Start()
+	defer c.StopAndWait()
+	for deadline := time.Now().Add(time.Second); c.User().ID == 0; time.Sleep(10 * time.Millisecond) {
+		if time.Now().After(deadline) {
+			t.Fatalf("timed out waiting for Start after %d getMe attempts", transport.count())
+		}
+	}
+	if n := transport.count(); n != 3 {
+		t.Errorf("expected 3 getMe attempts, got %d", n)
+	}
+
+	s.RespondError("getMe", 401, "Unauthorized")
+	c = s.Connection()
+	c.RetryDelay = time.Millisecond
+	errc := make(chan error, 1)
+	go func() { errc <- c.Start() }()
+	select {
+	case err := <-errc:
+		if e := (*telegram.APIError)(nil); !errors.As(err, &e) || e.ErrorCode != 401 {
+			t.Fatalf("expected the 401, got %v", err)
+		}
+	case <-time.After(time.Second):
+		c.StopAndWait()
+		t.Fatal("expected Start to fail fast on 401")
+	}
+	if n := len(s.Calls("getMe")); n != 2 {
+		t.Errorf("expected a single getMe attempt for the 401, got %d", n-1)
+	}
+}
+
+func TestSharedFloodWait(t *testing.T) {
+	s := telegramtest.NewServer()
+	defer s.Close()
+	mu, times := sync.Mutex{}, []time.Time{}
+	s.RespondFunc("sendMessage", func(call telegramtest.Call) interface{} {
+		mu.Lock()
+		defer mu.Unlock()
+		times = append(times, time.Now())
+		if len(times) == 1 {
+			return telegramtest.Error{Code: 429, Description: "Too Many Requests: retry after 1", RetryAfter: 1}
+		}
+		return json.RawMessage(`{"message_id": 1, "chat": {"id": 1}}`)
+	})
+	c := s.Connection()
+	wg, errs := sync.WaitGroup{}, make(chan error, 6)
+	send := func() {
+		defer wg.Done()
+		_, err := c.SendMessage(1, "hi")
+		errs <- err
+	}
+	wg.Add(1)
+	go send()
+	if _, err := s.WaitCall("sendMessage", 0, time.Second); err != nil {
+		t.Fatal(err)
+	}
+	time.Sleep(100 * time.Millisecond)
+	for i := 0; i < 5; i++ {
+		wg.Add(1)
+		go send()
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	if len(times) != 7 {
+		t.Fatalf("expected the rate limited send to be retried once, got %d calls", len(times))
+	}
+	for i, at := range times[1:] {
+		if wait := at.Sub(times[0]); wait < 900*time.Millisecond {
+			t.Errorf("send %d went out %s after the 429, expected all senders to wait for retry_after", i+1, wait)
+		}
+	}
+}
@@ -0,0 +1,71 @@
+package telegram
+
+import (
+	"encoding/json"
+	"reflect"
+	"sort"
+	"strings"
+)
+
+const subscriberBufferSize = 100
+
+func (c *Connection) Subscribe() <-chan Update {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	ch := make(chan Update, subscriberBufferSize)
+	c.subscribers = append(c.subscribers, ch)
+	return ch
+}
+
+func (c *Connection) Unsubscribe(ch <-chan Update) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for i, s := range c.subscribers {
+		if (<-chan Update)(s) == ch {
+			c.subscribers = append(c.subscribers[:i], c.subscribers[i+1:]...)
+			close(s)
+			return
+		}
+	}
+}
+
+func (c *Connection) publish(update map[string]json.RawMessage) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if len(c.subscribers) == 0 {
+		return
+	}
+	u, err := decodeUpdate(update)
+	if err != nil {
+		c.logf("publish update: %s", err)
+		return
+	}
+	for _, s := range c.subscribers {
+		select {
+		case s <- u:
+		default:
+			select {
+			case <-s:
+			default:
+			}
+			select {
+			case s <- u:
+			default:
+			}
+		}
+	}
+}
+
+// updateKinds returns all kinds of updates Update holds. As an empty
+// allowed_updates means Telegram's default kinds, which exclude e.g.
+// chat_member, subscribers request all kinds explicitly.
+func updateKinds() []string {
+	kinds, t := []string{}, reflect.TypeOf(Update{})
+	for i := 0; i < t.NumField(); i++ {
+		if kind := strings.Split(t.Field(i).Tag.Get("json"), ",")[0]; kind != "update_id" {
+			kinds = append(kinds, kind)
+		}
+	}
+	sort.Strings(kinds)
+	return kinds
+}
@@ -0,0 +1,42 @@
+package telegram_test
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/niklasfasching/telegram"
+	"github.com/niklasfasching/telegram/telegramtest"
+)
+
+func TestSubscribe(t *testing.T) {
+	s := telegramtest.NewServer()
+	defer s.Close()
+	c := s.Connection()
+	h := &telegramtest.Harness{Connection: c}
+	a, b := c.Subscribe(), c.Subscribe()
+	h.Inject(`{"update_id": 1, "message": {"message_id": 1, "chat": {"id": 1}, "text": "hi"}}`)
+	for _, ch := range []<-chan telegram.Update{a, b} {
+		select {
+		case u := <-ch:
+			if u.ID != 1 || u.Message == nil || u.Message.Text != "hi" {
+				t.Errorf("unexpected update %#v", u)
+			}
+		default:
+			t.Fatal("expected both subscribers to receive the update")
+		}
+	}
+
+	c.Unsubscribe(b)
+	if _, ok := <-b; ok {
+		t.Fatal("expected Unsubscribe to close the channel")
+	}
+	for i := 2; i <= 150; i++ {
+		h.Inject(fmt.Sprintf(`{"update_id": %d, "message": {"message_id": %d, "chat": {"id": 1}, "text": "hi"}}`, i, i))
+	}
+	if n := len(a); n != cap(a) {
+		t.Fatalf("expected a full buffer, got %d of %d", n, cap(a))
+	}
+	if u := <-a; u.ID != 150-cap(a)+1 {
+		t.Errorf("expected the oldest updates to be dropped, got update %d first", u.ID)
+	}
+}
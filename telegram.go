@@ -2,16 +2,23 @@ package telegram
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"io/ioutil"
 	"log"
+	"math/rand"
 	"mime/multipart"
 	"net/http"
 	"reflect"
+	"regexp"
+	"runtime/debug"
+	"sort"
 	"strconv"
 	"strings"
+	"sync"
 	"time"
 )
 
@@ -23,151 +30,840 @@ type API interface {
 	User() User
 }
 
+// Logger receives the log output of a Connection, e.g. a *log.Logger or an
+// adapter for a structured logger.
+type Logger interface {
+	Printf(format string, v ...interface{})
+}
+
 type Connection struct {
-	Token    string
-	Timeout  time.Duration
-	Debug    bool
-	handlers map[string]reflect.Value
-	user     User
-	offset   int
-	stopped  bool
+	Token      string
+	Timeout    time.Duration
+	Debug      bool
+	CheckToken bool
+
+	// Logger defaults to the standard logger; Debug output goes to it as well.
+	Logger Logger
+	// OnRequest and OnResponse are called around every HTTP request to the Bot
+	// API, including retries. errorCode is the Bot API error code, 0 for
+	// successful requests and failures without a response.
+	OnRequest  func(method string)
+	OnResponse func(method string, duration time.Duration, errorCode int, err error)
+	// OnUpdate is called after each update with the route that handled it, see
+	// Dispatch. OnHandlerError is additionally called for updates whose handling
+	// failed; unlike OnError it only observes and doesn't change what Start does.
+	OnUpdate       func(route string, duration time.Duration)
+	OnHandlerError func(route string, err error)
+
+	// OnError receives errors from handlers and update decoding. Polling then
+	// continues with the next update; without OnError, such errors stop Start.
+	// It is also told about transient failures that are being retried.
+	OnError func(error)
+	// OnChatMigrated is called after a group was upgraded to a supergroup with
+	// a new id, see ChatMigrated.
+	OnChatMigrated func(oldID, newID int64)
+	// AbortOnPanic makes a panicking handler stop polling with a *PanicError
+	// instead of logging the panic and continuing with the next update.
+	AbortOnPanic bool
+
+	// AllowedUpdates overrides the update kinds requested from getUpdates,
+	// which default to the kinds with a registered handler.
+	AllowedUpdates []string
+
+	// WebhookURL is the public https URL StartWebhook registers with Telegram.
+	// WebhookSecret, if set, is required in the secret token header of every
+	// webhook request.
+	WebhookURL    string
+	WebhookSecret string
+
+	// Concurrency, if greater than 1, handles each batch of updates on up to
+	// that many goroutines. Updates of the same chat may then be handled out
+	// of order unless OrderByChat is set, and handler errors go to OnError (or
+	// the log) without stopping polling. The offset only advances once the
+	// whole batch is done.
+	Concurrency int
+	OrderByChat bool
+
+	// Client is used for all requests. For getUpdates, its Timeout is replaced
+	// by a deadline of Timeout plus a margin, so the long poll isn't cut short.
+	Client *http.Client
+
+	// BaseURL replaces https://api.telegram.org, e.g. for a self-hosted Bot API
+	// server or an httptest.Server.
+	BaseURL      string
+	UserAgent    string
+	ExtraHeaders http.Header
+
+	BeforeEncode func(method string, params map[string]interface{})
+
+	RateLimitRetries int
+	// MaxRetries bounds how often a request failing with a network error is
+	// retried, with exponential backoff starting at RetryDelay. Defaults to 3;
+	// negative disables retries. API errors are never retried here.
+	MaxRetries int
+	RetryDelay time.Duration
+
+	ParseMode        ParseMode
+	MessageDecorator func(text string, mode ParseMode) string
+	// PlainTextFallback resends a message once without parse_mode if Telegram
+	// rejects its formatting with "can't parse entities". Uploads are excluded
+	// as their readers have already been consumed.
+	PlainTextFallback bool
+
+	ProtectContent       bool
+	ProtectContentByChat map[int64]bool
+
+	// TrackPolls remembers the messages of the last polls sent, see PollMessage.
+	TrackPolls bool
+
+	// MemberCountTTL caches GetChatMemberCount results per chat for that long.
+	MemberCountTTL time.Duration
+
+	// SendDebounce suppresses identical sends to a chat within that window;
+	// they fail with ErrDuplicate.
+	SendDebounce time.Duration
+	PaceSlowMode bool
+	ChatTypes    *ChatTypeFilter
+
+	// Throttle spaces out sends to stay within Telegram's limits of about 30
+	// messages per second overall, 1 per second per chat and 20 per minute per
+	// group, instead of running into 429s. Broadcast always throttles.
+	Throttle bool
+
+	// LocalMode is set when talking to a self-hosted Bot API server started with
+	// --local. LocalFile inputs are then sent as paths instead of being uploaded,
+	// downloads are read from the server's disk and the larger size limits apply.
+	LocalMode bool
+
+	// UploadProgress is called while a file upload is streamed with the number
+	// of bytes of the request body sent so far.
+	UploadProgress func(method string, sent int64)
+
+	OffsetStore         OffsetStore
+	OffsetFlushInterval time.Duration
+
+	// RetryBudget caps the total time spent waiting on retries per poll cycle:
+	// those of getUpdates and the network error retries of Call made by the
+	// cycle's handlers. Webhook and Dispatch updates get a budget each, and
+	// Start one for getMe. Once it is used up, a failing getUpdates makes
+	// Start return.
+	RetryBudget time.Duration
+
+	handlers  map[string][]updateHandler
+	commands  map[string]CommandFunc
+	topics    map[topic]func(context.Context, Message) error
+	user      User
+	offset    int
+	confirmed int
+	saved     int
+	mu        sync.Mutex
+	ctx       context.Context
+	cancel    context.CancelFunc
+	stopped   bool
+	wg        sync.WaitGroup
+
+	channelCommands map[string]CommandFunc
+	middleware      []Middleware
+	handlersMu      sync.RWMutex
+
+	callbacks map[string]CallbackFunc
+
+	subscribers []chan Update
+	sendQueue   []asyncSend
+	sendSignal  chan struct{}
+	sending     bool
+	recentSends map[string]time.Time
+
+	lastPoll      time.Time
+	pollFailures  int
+	webhook       bool
+	slowMode      map[int64]slowMode
+	floodUntil    time.Time
+	answered      map[string]struct{}
+	answeredOrder []string
+	polls         map[string]*Message
+	pollOrder     []string
+	memberCounts  map[int64]memberCount
+
+	nextSend     time.Time
+	nextChatSend map[int64]time.Time
+
+	clock   func() time.Time
+	sleeper func(context.Context, time.Duration) error
 }
 
+const defaultUserAgent = "niklasfasching-telegram"
+const defaultBaseURL = "https://api.telegram.org"
+const defaultTimeout = 10 * time.Second
+const longPollMargin = 10 * time.Second
+const defaultRateLimitRetries = 3
+const defaultMaxRetries = 3
+const defaultRetryDelay = 500 * time.Millisecond
+
+var tokenRegexp = regexp.MustCompile(`^[0-9]+:[A-Za-z0-9_-]{35}$`)
+
 type response struct {
-	OK          bool            `json:"ok"`
-	Result      json.RawMessage `json:"result"`
-	ErrorCode   int             `json:"error_code"`
-	Description string          `json:"description"`
+	OK          bool               `json:"ok"`
+	Result      json.RawMessage    `json:"result"`
+	ErrorCode   int                `json:"error_code"`
+	Description string             `json:"description"`
+	Parameters  ResponseParameters `json:"parameters"`
 }
 
-func (c *Connection) User() User { return c.user }
+type ResponseParameters struct {
+	RetryAfter      int   `json:"retry_after"`
+	MigrateToChatID int64 `json:"migrate_to_chat_id"`
+}
 
-func (c *Connection) Start() error {
-	if c.Timeout == 0 {
-		c.Timeout = 10 * time.Second
+func (c *Connection) User() User {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.user
+}
+
+func (c *Connection) newRequest(ctx context.Context, method, url string, body io.Reader) (*http.Request, error) {
+	req, err := http.NewRequestWithContext(ctx, method, url, body)
+	if err != nil {
+		return nil, err
+	}
+	for k, vs := range c.ExtraHeaders {
+		for _, v := range vs {
+			req.Header.Add(k, v)
+		}
+	}
+	if c.UserAgent != "" {
+		req.Header.Set("User-Agent", c.UserAgent)
+	} else {
+		req.Header.Set("User-Agent", defaultUserAgent)
+	}
+	return req, nil
+}
+
+func longPollClient(client *http.Client, timeout time.Duration) *http.Client {
+	if client.Timeout == 0 || client.Timeout >= timeout {
+		return client
+	}
+	longPoll := *client
+	longPoll.Timeout = 0
+	return &longPoll
+}
+
+func (c *Connection) client() *http.Client {
+	if c.Client != nil {
+		return c.Client
+	}
+	return http.DefaultClient
+}
+
+func (c *Connection) Start() error { return c.StartContext(context.Background()) }
+
+func (c *Connection) StartContext(parent context.Context) error {
+	return c.run(parent, func(ctx context.Context) error {
+		if err := c.loadOffset(); err != nil {
+			return err
+		}
+		for ctx.Err() == nil {
+			if err := c.handleUpdates(ctx); err != nil && ctx.Err() == nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+func (c *Connection) run(parent context.Context, receive func(ctx context.Context) error) error {
+	if c.CheckToken {
+		if err := ValidateToken(c.Token); err != nil {
+			return err
+		}
+	}
+	ctx, ok := c.lifecycle()
+	if !ok {
+		return nil
 	}
+	defer c.wg.Done()
+	defer c.shutdown()
+	c.goroutine(func(ctx context.Context) {
+		select {
+		case <-parent.Done():
+			c.shutdown()
+		case <-ctx.Done():
+		}
+	})
 	user := User{}
-	if err := c.Call("getMe", nil, &user); err != nil {
+	if err := c.retry(withRetryBudget(ctx), "getMe", func() error { return c.CallContext(ctx, "getMe", nil, &user) }); err != nil {
+		if ctx.Err() != nil {
+			return nil
+		}
 		return err
 	}
+	c.mu.Lock()
 	c.user = user
+	c.mu.Unlock()
 	if c.Debug {
-		log.Println("Started:", prettyPrintJSON(c.user))
+		c.logf("Started: %s", prettyPrintJSON(user))
 	}
-	for !c.stopped {
-		if err := c.handleUpdates(); err != nil {
-			return err
-		}
+	c.handlersMu.RLock()
+	_, hasMessageHandler := c.handlers["message"]
+	hasMessageHandler = hasMessageHandler || len(c.topics) != 0
+	c.handlersMu.RUnlock()
+	if hasMessageHandler && !user.CanReadAllGroupMessages {
+		c.logf("Warning: privacy mode is enabled, message handlers will only receive commands and replies in groups")
 	}
-	return nil
+	return receive(ctx)
+}
+
+// Stop stops Start and the background goroutines of c. It is final: a Stop
+// before Start makes Start return right away.
+func (c *Connection) Stop() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.stopped = true
+	if c.cancel != nil {
+		c.cancel()
+	}
+}
+
+func (c *Connection) StopAndWait() {
+	c.Stop()
+	c.wg.Wait()
+}
+
+func (c *Connection) shutdown() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.cancel != nil {
+		c.cancel()
+	}
+}
+
+// lifecycle returns the context shared by Start and all background goroutines
+// and registers the caller with c.wg. Once c is stopped, it returns a done
+// context and false instead.
+func (c *Connection) lifecycle() (context.Context, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.stopped {
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+		return ctx, false
+	} else if c.ctx == nil || c.ctx.Err() != nil {
+		c.ctx, c.cancel = context.WithCancel(context.Background())
+	}
+	c.wg.Add(1)
+	return c.ctx, true
 }
 
-func (c *Connection) Stop() { c.stopped = true }
+// goroutine runs f in the background until c is stopped. After Stop, f runs
+// right away with a done context so it can still clean up.
+func (c *Connection) goroutine(f func(ctx context.Context)) {
+	ctx, ok := c.lifecycle()
+	if !ok {
+		f(ctx)
+		return
+	}
+	go func() {
+		defer c.wg.Done()
+		f(ctx)
+	}()
+}
 
 func (c *Connection) Call(method string, data, result interface{}) error {
-	url := fmt.Sprintf("https://api.telegram.org/bot%s/%s", c.Token, method)
-	m, err := toMap(data)
+	return c.CallContext(context.Background(), method, data, result)
+}
+
+func (c *Connection) CallContext(ctx context.Context, method string, data, result interface{}) error {
+	url := fmt.Sprintf("%s/bot%s/%s", c.baseURL(), c.Token, method)
+	m, err := c.render(method, data)
 	if err != nil {
 		return err
 	}
-	body, contentType, err := encodeMultipartBody(m)
+	if c.duplicateSend(method, m) {
+		c.logf("%s: suppressed duplicate send to %v", method, m["chat_id"])
+		return fmt.Errorf("%s to %v: %w", method, m["chat_id"], ErrDuplicate)
+	}
+	if err := c.paceSlowMode(ctx, method, m); err != nil {
+		return err
+	} else if err := c.throttle(ctx, method, m); err != nil {
+		return err
+	}
+	if c.BeforeEncode != nil {
+		c.BeforeEncode(method, m)
+	}
+	closeFiles, err := c.openLocalFiles(m)
 	if err != nil {
 		return err
 	}
-	req, err := http.NewRequest("POST", url, body)
+	defer closeFiles()
+	body, err := newRequestBody(m)
 	if err != nil {
 		return err
 	}
+	defer func() { body.close() }()
+	client := c.client()
+	if method == "getUpdates" {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, c.timeout()+longPollMargin)
+		defer cancel()
+		client = longPollClient(client, c.timeout()+longPollMargin)
+	}
+	migrated := false
+	for attempt, netAttempt := 0, 0; ; attempt++ {
+		if err := c.waitFlood(ctx, method); err != nil {
+			return err
+		}
+		r, err := c.post(ctx, client, method, url, body)
+		if d := c.retryDelay(netAttempt); err != nil && method != "getUpdates" && isNetworkError(err) && ctx.Err() == nil && body.rewindable() && netAttempt < c.maxRetries() && spendRetryBudget(ctx, c.RetryBudget, d) {
+			netAttempt++
+			c.debugLog(tracePrefix(ctx, method), []byte(fmt.Sprintf("%s, retrying in %s", err, d)))
+			if err := sleep(ctx, d); err != nil {
+				return err
+			}
+			continue
+		} else if err != nil {
+			return err
+		}
+		if !r.OK {
+			if retryAfter := r.Parameters.RetryAfter; r.ErrorCode == 429 && retryAfter > 0 {
+				c.setFlood(time.Duration(retryAfter) * time.Second)
+				if attempt < c.rateLimitRetries() && body.rewindable() {
+					c.debugLog(tracePrefix(ctx, method), []byte(fmt.Sprintf("rate limited, retrying in %ds", retryAfter)))
+					continue
+				}
+			}
+			if c.PlainTextFallback && isParseError(r) && m["parse_mode"] != nil && !hasReader(m) {
+				c.logf("%s: %s, retrying as plain text", method, r.Description)
+				delete(m, "parse_mode")
+				if err := body.refresh(); err != nil {
+					return err
+				}
+				continue
+			}
+			if oldID, ok := chatIDParam(m); ok && r.Parameters.MigrateToChatID != 0 {
+				c.ChatMigrated(oldID, r.Parameters.MigrateToChatID)
+				if !migrated && body.rewindable() {
+					c.debugLog(tracePrefix(ctx, method), []byte(fmt.Sprintf("chat %d migrated, retrying with %d", oldID, r.Parameters.MigrateToChatID)))
+					migrated, m["chat_id"] = true, r.Parameters.MigrateToChatID
+					if err := body.refresh(); err != nil {
+						return err
+					}
+					continue
+				}
+			}
+			return &APIError{Method: method, ErrorCode: r.ErrorCode, Description: r.Description, Parameters: r.Parameters, data: data}
+		}
+		c.trackPoll(method, r.Result)
+		if result != nil {
+			return unmarshalJSON(r.Result, result)
+		}
+		return nil
+	}
+}
+
+// RenderRequest returns the params Call would send for method and data, after
+// defaults such as ParseMode and ProtectContent have been applied, without
+// sending anything. multipart reports whether the request uploads files.
+func (c *Connection) RenderRequest(method string, data interface{}) (params map[string]interface{}, multipart bool, err error) {
+	m, err := c.render(method, data)
+	if err != nil {
+		return nil, false, err
+	}
+	upload := func(v interface{}) bool {
+		_, ok := v.(LocalFile)
+		return ok && !c.LocalMode
+	}
+	for _, v := range m {
+		multipart = multipart || upload(v)
+	}
+	for _, item := range mediaItems(m) {
+		for _, v := range item {
+			multipart = multipart || upload(v)
+		}
+	}
+	return m, multipart || hasReader(m), nil
+}
+
+func (c *Connection) render(method string, data interface{}) (map[string]interface{}, error) {
+	m, err := toMap(data)
+	if err != nil {
+		return nil, err
+	}
+	if err := c.decorate(method, m); err != nil {
+		return nil, err
+	}
+	if err := validateEntities(method, m); err != nil {
+		return nil, err
+	} else if err := validateMessageEffect(method, m); err != nil {
+		return nil, err
+	}
+	c.protectContent(method, m)
+	return m, nil
+}
+
+func (c *Connection) post(ctx context.Context, client *http.Client, method, url string, body *requestBody) (response, error) {
+	r := response{}
+	var progress func(sent int64)
+	if c.UploadProgress != nil {
+		progress = func(sent int64) { c.UploadProgress(method, sent) }
+	}
+	reader, contentType, err := body.open(progress)
+	if err != nil {
+		return r, err
+	}
+	req, err := c.newRequest(ctx, "POST", url, reader)
+	if err != nil {
+		return r, err
+	}
 	req.Header.Set("Content-Type", contentType)
-	res, err := http.DefaultClient.Do(req)
+	if c.OnRequest != nil {
+		c.OnRequest(method)
+	}
+	start := time.Now()
+	if c.OnResponse != nil {
+		defer func() { c.OnResponse(method, time.Since(start), r.ErrorCode, err) }()
+	}
+	res, err := client.Do(req)
 	if err != nil {
-		return err
+		return r, err
 	}
 	defer res.Body.Close()
 	bs, err := ioutil.ReadAll(res.Body)
 	if err != nil {
-		return err
+		return r, err
 	}
-	debugLog(c.Debug, method, bs)
-	r := response{}
-	if err = json.Unmarshal(bs, &r); err != nil {
-		return err
+	c.debugLog(tracePrefix(ctx, method), bs)
+	err = json.Unmarshal(bs, &r)
+	return r, err
+}
+
+func (c *Connection) maxRetries() int {
+	if c.MaxRetries == 0 {
+		return defaultMaxRetries
 	}
-	if !r.OK {
-		return fmt.Errorf("%s (%d) (%s: %s)", r.Description, r.ErrorCode, method, prettyPrintJSON(data))
+	return c.MaxRetries
+}
+
+func (c *Connection) retryDelay(attempt int) time.Duration {
+	d := c.RetryDelay
+	if d == 0 {
+		d = defaultRetryDelay
 	}
-	if result != nil {
-		return json.Unmarshal(r.Result, result)
+	d <<= attempt
+	return d/2 + time.Duration(rand.Int63n(int64(d/2)+1))
+}
+
+func (c *Connection) timeout() time.Duration {
+	if c.Timeout == 0 {
+		return defaultTimeout
 	}
-	return nil
+	return c.Timeout
+}
+
+func (c *Connection) baseURL() string {
+	if c.BaseURL == "" {
+		return defaultBaseURL
+	}
+	return strings.TrimSuffix(c.BaseURL, "/")
+}
+
+func (c *Connection) rateLimitRetries() int {
+	if c.RateLimitRetries == 0 {
+		return defaultRateLimitRetries
+	}
+	return c.RateLimitRetries
 }
 
-func (c *Connection) handleUpdates() error {
+func (c *Connection) handleUpdates(ctx context.Context) error {
 	updates, data := []map[string]json.RawMessage{}, map[string]interface{}{
 		"offset":  c.offset,
-		"timeout": c.Timeout.Seconds(),
+		"timeout": c.timeout().Seconds(),
 	}
-	if err := c.Call("getUpdates", data, &updates); err != nil {
+	data["allowed_updates"] = c.allowedUpdates()
+	ctx = withRetryBudget(ctx)
+	if err := c.retry(ctx, "getUpdates", func() error {
+		err := c.CallContext(ctx, "getUpdates", data, &updates)
+		c.recordPollFailure(err)
 		return err
+	}); err != nil {
+		return err
+	}
+	c.mu.Lock()
+	c.lastPoll, c.pollFailures = time.Now(), 0
+	c.mu.Unlock()
+	if c.Concurrency > 1 {
+		return c.handleUpdatesConcurrently(ctx, updates)
 	}
 	for _, u := range updates {
 		offset, err := strconv.Atoi(string(u["update_id"]))
 		if err != nil {
 			return err
 		}
-		c.offset = offset + 1
-		if err := c.handleUpdate(u); err != nil {
+		if offset+1 > c.offset {
+			c.offset = offset + 1
+		}
+		if _, err := c.safeHandleUpdate(withTraceID(ctx, u), u); err != nil && c.OnError != nil {
+			c.confirmOffset(offset + 1)
+			c.OnError(err)
+			continue
+		} else if err != nil {
+			c.saveOffset()
 			return err
 		}
+		c.confirmOffset(offset + 1)
 	}
-	return nil
+	return c.saveOffset()
 }
 
-func (c *Connection) handleUpdate(update map[string]json.RawMessage) error {
-	for kind, handler := range c.handlers {
-		if update[kind] == nil {
-			continue
-		}
-		debugLog(c.Debug, kind, []byte(prettyPrintJSON(update)))
-		v := reflect.New(handler.Type().In(0))
-		if err := json.Unmarshal(update[kind], v.Interface()); err != nil {
+func (c *Connection) handleUpdatesConcurrently(ctx context.Context, updates []map[string]json.RawMessage) error {
+	groups, chatGroups := [][]map[string]json.RawMessage{}, map[int64]int{}
+	for _, u := range updates {
+		offset, err := strconv.Atoi(string(u["update_id"]))
+		if err != nil {
 			return err
 		}
-		if err := handler.Call([]reflect.Value{v.Elem()})[0].Interface(); err != nil {
-			return err.(error)
+		if offset+1 > c.offset {
+			c.offset = offset + 1
 		}
-		return nil
+		if chatID, ok := updateChatID(u); ok && c.OrderByChat {
+			if i, ok := chatGroups[chatID]; ok {
+				groups[i] = append(groups[i], u)
+				continue
+			}
+			chatGroups[chatID] = len(groups)
+		}
+		groups = append(groups, []map[string]json.RawMessage{u})
+	}
+	sem, wg := make(chan struct{}, c.Concurrency), sync.WaitGroup{}
+	for _, group := range groups {
+		sem <- struct{}{}
+		wg.Add(1)
+		go func(group []map[string]json.RawMessage) {
+			defer func() { <-sem; wg.Done() }()
+			for _, u := range group {
+				if _, err := c.safeHandleUpdate(withTraceID(ctx, u), u); err != nil && c.OnError != nil {
+					c.OnError(err)
+				} else if err != nil {
+					c.logf("%s", err)
+				}
+			}
+		}(group)
+	}
+	wg.Wait()
+	c.confirmOffset(c.offset)
+	return c.saveOffset()
+}
+
+func (c *Connection) allowedUpdates() []string {
+	if c.AllowedUpdates != nil {
+		return c.AllowedUpdates
+	}
+	c.mu.Lock()
+	subscribed := len(c.subscribers) != 0
+	c.mu.Unlock()
+	if subscribed {
+		return updateKinds()
+	}
+	kinds := []string{}
+	c.handlersMu.RLock()
+	defer c.handlersMu.RUnlock()
+	for kind := range c.handlers {
+		kinds = append(kinds, kind)
+	}
+	if _, ok := c.handlers["message"]; !ok && (len(c.commands) != 0 || len(c.topics) != 0) {
+		kinds = append(kinds, "message")
+	}
+	for _, kind := range []string{"channel_post", "edited_channel_post"} {
+		if _, ok := c.handlers[kind]; !ok && len(c.channelCommands) != 0 {
+			kinds = append(kinds, kind)
+		}
+	}
+	if _, ok := c.handlers["callback_query"]; !ok && len(c.callbacks) != 0 {
+		kinds = append(kinds, "callback_query")
+	}
+	sort.Strings(kinds)
+	return kinds
+}
+
+// Dispatch handles a single update the way the poll loop does and returns the
+// route that handled it, e.g. "command:start", "message" or "unhandled".
+func (c *Connection) Dispatch(ctx context.Context, update []byte) (string, error) {
+	u := map[string]json.RawMessage{}
+	if err := json.Unmarshal(update, &u); err != nil {
+		return "", err
+	}
+	if _, ok := ctx.Value(retryBudgetKey{}).(*retryBudget); !ok {
+		ctx = withRetryBudget(ctx)
+	}
+	return c.safeHandleUpdate(withTraceID(ctx, u), u)
+}
+
+func (c *Connection) safeHandleUpdate(ctx context.Context, update map[string]json.RawMessage) (route string, err error) {
+	start := time.Now()
+	defer func() {
+		if c.OnUpdate != nil {
+			c.OnUpdate(route, time.Since(start))
+		}
+		if c.OnHandlerError != nil && err != nil {
+			c.OnHandlerError(route, err)
+		}
+	}()
+	defer func() {
+		if v := recover(); v != nil {
+			route, err = "panic", &PanicError{Value: v, Update: prettyPrintJSON(update), Stack: string(debug.Stack())}
+			if !c.AbortOnPanic {
+				c.logf("%s", err)
+				err = nil
+			}
+		}
+	}()
+	return c.handleUpdate(ctx, update)
+}
+
+func (c *Connection) handleUpdate(ctx context.Context, update map[string]json.RawMessage) (string, error) {
+	c.migrateFromUpdate(update)
+	c.publish(update)
+	c.handlersMu.RLock()
+	middleware := c.middleware
+	c.handlersMu.RUnlock()
+	if len(middleware) == 0 {
+		return c.dispatch(ctx, update)
+	}
+	u, err := decodeUpdate(update)
+	if err != nil {
+		return "", err
+	}
+	route := "middleware"
+	h := Handler(func(ctx context.Context, _ Update) (err error) {
+		route, err = c.dispatch(ctx, update)
+		return err
+	})
+	for i := len(middleware) - 1; i >= 0; i-- {
+		h = middleware[i](h)
+	}
+	return route, h(ctx, u)
+}
+
+func (c *Connection) dispatch(ctx context.Context, update map[string]json.RawMessage) (string, error) {
+	if ok, err := c.filterChatType(ctx, update); !ok || err != nil {
+		return "filtered", err
+	}
+	handled := ""
+	for _, handle := range []func(context.Context, map[string]json.RawMessage) (string, error){c.handleMessage, c.handleCallback} {
+		if route, err := handle(ctx, update); errors.Is(err, ErrContinue) {
+			handled = route
+		} else if route != "" || err != nil {
+			return route, err
+		}
+	}
+	kind, handlers := c.handler(update)
+	for _, handler := range handlers {
+		c.debugLog(tracePrefix(ctx, kind), []byte(prettyPrintJSON(update)))
+		if err := handler(ctx, update[kind]); errors.Is(err, ErrContinue) {
+			handled = kind
+		} else {
+			return kind, err
+		}
+	}
+	if handled != "" {
+		return handled, nil
+	}
+	c.debugLog(tracePrefix(ctx, "unhandled"), []byte(prettyPrintJSON(update)))
+	return "unhandled", nil
+}
+
+func callHandler(ctx context.Context, handler reflect.Value, data json.RawMessage) error {
+	t := handler.Type()
+	v := reflect.New(t.In(t.NumIn() - 1))
+	if err := unmarshalJSON(data, v.Interface()); err != nil {
+		return err
+	}
+	args := []reflect.Value{v.Elem()}
+	if t.NumIn() == 2 {
+		args = []reflect.Value{reflect.ValueOf(ctx), v.Elem()}
+	}
+	if err := handler.Call(args)[0].Interface(); err != nil {
+		return err.(error)
 	}
-	debugLog(c.Debug, "unhandled", []byte(prettyPrintJSON(update)))
 	return nil
 }
 
+func (c *Connection) handler(update map[string]json.RawMessage) (string, []updateHandler) {
+	c.handlersMu.RLock()
+	defer c.handlersMu.RUnlock()
+	for kind, handlers := range c.handlers {
+		if update[kind] != nil {
+			return kind, handlers
+		}
+	}
+	return "", nil
+}
+
+// Handle registers handlerFunc for updates of the given kind. Multiple handlers
+// of a kind run in the order they were registered until one returns something
+// other than ErrContinue.
 func (c *Connection) Handle(kind string, handlerFunc interface{}) {
+	c.HandleAll([]string{kind}, handlerFunc)
+}
+
+func (c *Connection) HandleAll(kinds []string, handlerFunc interface{}) {
 	v := reflect.ValueOf(handlerFunc)
-	if t := v.Type(); t.NumIn() != 1 || t.NumOut() != 1 || t.Out(0) != reflect.TypeOf((*error)(nil)).Elem() {
-		panic(fmt.Errorf("handlerFunc must be in the format func(T) error"))
+	t, ctxType := v.Type(), reflect.TypeOf((*context.Context)(nil)).Elem()
+	if n := t.NumIn(); n != 1 && (n != 2 || t.In(0) != ctxType) || t.NumOut() != 1 || t.Out(0) != reflect.TypeOf((*error)(nil)).Elem() {
+		panic(fmt.Errorf("handlerFunc must be in the format func(T) error or func(context.Context, T) error"))
 	}
-	if _, ok := c.handlers[kind]; ok {
-		panic(fmt.Errorf("handler for event kind %s has already been registered", kind))
+	h := func(ctx context.Context, data json.RawMessage) error { return callHandler(ctx, v, data) }
+	for _, kind := range kinds {
+		c.on(kind, h)
 	}
+}
+
+// updateHandler decodes the data of an update of its kind and handles it.
+type updateHandler func(ctx context.Context, data json.RawMessage) error
+
+func (c *Connection) on(kind string, h updateHandler) {
+	c.handlersMu.Lock()
+	defer c.handlersMu.Unlock()
 	if c.handlers == nil {
-		c.handlers = map[string]reflect.Value{}
+		c.handlers = map[string][]updateHandler{}
 	}
-	c.handlers[kind] = v
+	c.handlers[kind] = append(c.handlers[kind], h)
 }
 
-func debugLog(debug bool, prefix string, bytes []byte) {
-	if !debug {
+func ValidateToken(token string) error {
+	if !tokenRegexp.MatchString(token) {
+		return fmt.Errorf("malformed bot token: expected <bot_id>:<35 character hash>")
+	}
+	return nil
+}
+
+func (c *Connection) debugLog(prefix string, bytes []byte) {
+	if !c.Debug {
 		return
 	}
 	m := map[string]interface{}{}
-	if err := json.Unmarshal(bytes, &m); err != nil {
-		log.Println(prefix, string(bytes))
+	if err := unmarshalJSON(bytes, &m); err != nil {
+		c.logf("%s %s", prefix, string(bytes))
 	} else {
-		log.Println(prefix, prettyPrintJSON(m))
+		c.logf("%s %s", prefix, prettyPrintJSON(m))
 	}
 }
 
+func (c *Connection) logf(format string, v ...interface{}) {
+	if c.Logger != nil {
+		c.Logger.Printf(format, v...)
+	} else {
+		log.Printf(format, v...)
+	}
+}
+
+// unmarshalJSON decodes numbers into interface{} values as json.Number rather
+// than float64, which can't represent ids and file sizes beyond 2^53 exactly.
+func unmarshalJSON(bs []byte, v interface{}) error {
+	d := json.NewDecoder(bytes.NewReader(bs))
+	d.UseNumber()
+	return d.Decode(v)
+}
+
 func prettyPrintJSON(v interface{}) string {
 	out := strings.Builder{}
 	json := json.NewEncoder(&out)
@@ -177,37 +873,59 @@ func prettyPrintJSON(v interface{}) string {
 	return out.String()
 }
 
-func encodeMultipartBody(data map[string]interface{}) (io.Reader, string, error) {
+func encodeMultipartBody(data map[string]interface{}) (*bytes.Buffer, string, error) {
 	if len(data) == 0 {
 		return &bytes.Buffer{}, "application/json", nil
 	}
 	body := &bytes.Buffer{}
 	form := multipart.NewWriter(body)
+	return body, form.FormDataContentType(), writeMultipartBody(form, data)
+}
+
+func writeMultipartBody(form *multipart.Writer, data map[string]interface{}) error {
 	for k, v := range data {
 		switch v := v.(type) {
 		case io.Reader:
 			w, err := form.CreateFormFile(k, k)
 			if err != nil {
-				return nil, "", err
+				return err
 			}
 			if _, err = io.Copy(w, v); err != nil {
-				return nil, "", err
+				return err
 			}
 		case string:
 			if err := form.WriteField(k, v); err != nil {
-				return nil, "", err
+				return err
 			}
 		default:
 			bs, err := json.Marshal(v)
 			if err != nil {
-				return nil, "", err
+				return err
 			}
 			if err := form.WriteField(k, string(bs)); err != nil {
-				return nil, "", err
+				return err
 			}
 		}
 	}
-	return body, form.FormDataContentType(), form.Close()
+	return form.Close()
+}
+
+func isNil(v reflect.Value) bool {
+	switch v.Kind() {
+	case reflect.Ptr, reflect.Map, reflect.Slice, reflect.Interface:
+		return v.IsNil()
+	}
+	return false
+}
+
+// isEmpty is like encoding/json's omitempty, except that zero structs are
+// empty as well.
+func isEmpty(v reflect.Value) bool {
+	switch v.Kind() {
+	case reflect.Array, reflect.Map, reflect.Slice, reflect.String:
+		return v.Len() == 0
+	}
+	return v.IsZero()
 }
 
 func toMap(data interface{}) (map[string]interface{}, error) {
@@ -222,17 +940,23 @@ func toMap(data interface{}) (map[string]interface{}, error) {
 	case reflect.Struct:
 		t := v.Type()
 		for i := 0; i < v.NumField(); i++ {
-			k := t.Field(i).Name
-			if tag := t.Field(i).Tag.Get("json"); tag != "" {
-				if jsonKey := strings.Split(tag, ",")[0]; jsonKey != "" {
-					k = jsonKey
-				}
+			f, k := v.Field(i), t.Field(i).Name
+			tag := strings.Split(t.Field(i).Tag.Get("json"), ",")
+			if tag[0] == "-" || t.Field(i).PkgPath != "" || isNil(f) {
+				continue
+			} else if tag[0] != "" {
+				k = tag[0]
+			}
+			if len(tag) > 1 && strings.Contains(","+strings.Join(tag[1:], ",")+",", ",omitempty,") && isEmpty(f) {
+				continue
 			}
-			m[k] = v.Field(i).Interface()
+			m[k] = f.Interface()
 		}
 	case reflect.Map:
 		for _, k := range v.MapKeys() {
-			m[k.String()] = v.MapIndex(k).Interface()
+			if f := v.MapIndex(k); !isNil(f) {
+				m[k.String()] = f.Interface()
+			}
 		}
 	default:
 		return nil, fmt.Errorf("cannot toMap %s", v.Kind())
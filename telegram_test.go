@@ -0,0 +1,623 @@
+package telegram_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/niklasfasching/telegram"
+	"github.com/niklasfasching/telegram/telegramtest"
+)
+
+type memoryOffsetStore struct {
+	sync.Mutex
+	offset, saves int
+}
+
+func (s *memoryOffsetStore) Load() (int, error) {
+	s.Lock()
+	defer s.Unlock()
+	return s.offset, nil
+}
+
+func (s *memoryOffsetStore) Save(offset int) error {
+	s.Lock()
+	defer s.Unlock()
+	s.offset, s.saves = offset, s.saves+1
+	return nil
+}
+
+func TestStopAndWait(t *testing.T) {
+	s := telegramtest.NewServer()
+	defer s.Close()
+	c := s.Connection()
+	c.OffsetStore, c.OffsetFlushInterval = &memoryOffsetStore{}, time.Hour
+	c.Handle("message", func(m telegram.Message) error { return nil })
+	errc := make(chan error, 1)
+	go func() { errc <- c.Start() }()
+	s.InjectUpdate(`{"message": {"message_id": 1, "chat": {"id": 1}, "text": "hi"}}`)
+	results := []<-chan telegram.SendResult{}
+	for i := 0; i < 20; i++ {
+		results = append(results, c.SendAsync("sendMessage", map[string]interface{}{"chat_id": 1, "text": "hi"}))
+	}
+	if _, err := s.WaitCall("getMe", 0, time.Second); err != nil {
+		t.Fatal(err)
+	}
+	done := make(chan struct{})
+	go func() { c.StopAndWait(); close(done) }()
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("StopAndWait did not return")
+	}
+	if err := <-errc; err != nil {
+		t.Fatal(err)
+	}
+	for _, r := range results {
+		if res := <-r; res.Err != nil && res.Err != telegram.ErrStopped {
+			t.Fatal(res.Err)
+		}
+	}
+	if res := <-c.SendAsync("sendMessage", map[string]interface{}{"chat_id": 1, "text": "late"}); res.Err != telegram.ErrStopped {
+		t.Fatalf("expected ErrStopped after Stop, got %v", res.Err)
+	}
+}
+
+func TestStopBeforeStart(t *testing.T) {
+	s := telegramtest.NewServer()
+	defer s.Close()
+	c := s.Connection()
+	c.Stop()
+	errc := make(chan error, 1)
+	go func() { errc <- c.Start() }()
+	select {
+	case err := <-errc:
+		if err != nil {
+			t.Fatal(err)
+		}
+	case <-time.After(time.Second):
+		c.StopAndWait()
+		t.Fatal("Start ignored the earlier Stop")
+	}
+	if calls := s.Calls(""); len(calls) != 0 {
+		t.Fatalf("expected no calls, got %v", calls)
+	}
+}
+
+func TestStopAndWaitInFlight(t *testing.T) {
+	tests := []struct {
+		name   string
+		setup  func(c *telegram.Connection)
+		handle func(ctx context.Context, c *telegram.Connection) error
+	}{
+		{"worker pool", func(c *telegram.Connection) {
+			c.Concurrency, c.OrderByChat = 4, true
+		}, func(ctx context.Context, c *telegram.Connection) error {
+			<-ctx.Done()
+			return nil
+		}},
+		{"throttled broadcast", func(c *telegram.Connection) {
+			c.Throttle = true
+		}, func(ctx context.Context, c *telegram.Connection) error {
+			ids := make([]int64, 1000)
+			for i := range ids {
+				ids[i] = int64(i + 1)
+			}
+			if failed := c.Broadcast(ctx, ids, "sendMessage", map[string]interface{}{"text": "hi"}, nil); len(failed) == 0 {
+				return errors.New("expected Stop to cut the broadcast short")
+			}
+			return nil
+		}},
+		{"chat action", func(c *telegram.Connection) {}, func(ctx context.Context, c *telegram.Connection) error {
+			return c.WithChatAction(ctx, 1, telegram.ChatActionTyping, func() error {
+				<-ctx.Done()
+				return nil
+			})
+		}},
+	}
+	for _, test := range tests {
+		s := telegramtest.NewServer()
+		c := s.Connection()
+		test.setup(c)
+		mu, errs, running := sync.Mutex{}, []error{}, 0
+		c.OnError = func(err error) {
+			mu.Lock()
+			defer mu.Unlock()
+			errs = append(errs, err)
+		}
+		started := make(chan struct{}, 10)
+		c.Handle("message", func(ctx context.Context, m telegram.Message) error {
+			mu.Lock()
+			running++
+			mu.Unlock()
+			defer func() {
+				mu.Lock()
+				running--
+				mu.Unlock()
+			}()
+			started <- struct{}{}
+			return test.handle(ctx, c)
+		})
+		for i := 1; i <= 6; i++ {
+			s.InjectUpdate(fmt.Sprintf(`{"message": {"message_id": %d, "chat": {"id": %d}, "text": "hi"}}`, i, i%3+1))
+		}
+		go c.Start()
+		select {
+		case <-started:
+		case <-time.After(time.Second):
+			t.Fatalf("%s: no update was handled", test.name)
+		}
+		done := make(chan struct{})
+		go func() { c.StopAndWait(); close(done) }()
+		select {
+		case <-done:
+		case <-time.After(5 * time.Second):
+			t.Fatalf("%s: StopAndWait did not return", test.name)
+		}
+		mu.Lock()
+		if running != 0 || len(errs) != 0 {
+			t.Errorf("%s: StopAndWait returned with %d handlers running, errors: %v", test.name, running, errs)
+		}
+		mu.Unlock()
+		s.Close()
+	}
+}
+
+// pollTransport records the form values of getUpdates requests, which the
+// fake server doesn't record.
+type pollTransport struct {
+	sync.Mutex
+	polls []url.Values
+}
+
+func (t *pollTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if strings.HasSuffix(req.URL.Path, "/getUpdates") {
+		bs, err := ioutil.ReadAll(req.Body)
+		if err != nil {
+			return nil, err
+		}
+		req.Body = ioutil.NopCloser(bytes.NewReader(bs))
+		form := &http.Request{Method: "POST", Header: req.Header, Body: ioutil.NopCloser(bytes.NewReader(bs))}
+		if err := form.ParseMultipartForm(1 << 20); err != nil {
+			return nil, err
+		}
+		t.Lock()
+		t.polls = append(t.polls, form.MultipartForm.Value)
+		t.Unlock()
+	}
+	return http.DefaultTransport.RoundTrip(req)
+}
+
+func (t *pollTransport) allowedUpdates(timeout time.Duration) []string {
+	for deadline := time.Now().Add(timeout); time.Now().Before(deadline); time.Sleep(10 * time.Millisecond) {
+		t.Lock()
+		polls := t.polls
+		t.Unlock()
+		if len(polls) != 0 {
+			kinds := []string{}
+			json.Unmarshal([]byte(polls[0].Get("allowed_updates")), &kinds)
+			return kinds
+		}
+	}
+	return nil
+}
+
+func TestAllowedUpdates(t *testing.T) {
+	s := telegramtest.NewServer()
+	defer s.Close()
+	tests := []struct {
+		name     string
+		register func(c *telegram.Connection)
+		want     []string
+	}{
+		{"handlers", func(c *telegram.Connection) {
+			c.Handle("message", func(telegram.Message) error { return nil })
+			c.Handle("chat_member", func(telegram.ChatMemberUpdated) error { return nil })
+		}, []string{"chat_member", "message"}},
+		{"commands and callbacks", func(c *telegram.Connection) {
+			c.HandleCommand("start", func(context.Context, telegram.Message, []string) error { return nil })
+			c.HandleCallback("vote:", func(context.Context, telegram.CallbackQuery, string) error { return nil })
+		}, []string{"callback_query", "message"}},
+		{"channel commands", func(c *telegram.Connection) {
+			c.HandleChannelCommand("post", func(context.Context, telegram.Message, []string) error { return nil })
+		}, []string{"channel_post", "edited_channel_post"}},
+		{"override", func(c *telegram.Connection) {
+			c.Handle("message", func(telegram.Message) error { return nil })
+			c.AllowedUpdates = []string{"poll"}
+		}, []string{"poll"}},
+	}
+	for _, test := range tests {
+		transport := &pollTransport{}
+		c := s.Connection()
+		c.Client = &http.Client{Transport: transport}
+		test.register(c)
+		go c.Start()
+		got := transport.allowedUpdates(time.Second)
+		c.StopAndWait()
+		if !reflect.DeepEqual(got, test.want) {
+			t.Errorf("%s: allowed_updates = %v, want %v", test.name, got, test.want)
+		}
+	}
+}
+
+func TestAllowedUpdatesSubscriber(t *testing.T) {
+	s := telegramtest.NewServer()
+	defer s.Close()
+	transport := &pollTransport{}
+	c := s.Connection()
+	c.Client = &http.Client{Transport: transport}
+	c.Handle("message", func(telegram.Message) error { return nil })
+	c.Subscribe()
+	go c.Start()
+	got := transport.allowedUpdates(time.Second)
+	c.StopAndWait()
+	kinds := map[string]bool{}
+	for _, kind := range got {
+		kinds[kind] = true
+	}
+	for _, kind := range []string{"message", "callback_query", "chat_member", "message_reaction", "message_reaction_count"} {
+		if !kinds[kind] {
+			t.Errorf("allowed_updates %v lacks %s", got, kind)
+		}
+	}
+}
+
+func TestGenericDecodePrecision(t *testing.T) {
+	const size = "9007199254740993"
+	s := telegramtest.NewServer()
+	defer s.Close()
+	c := s.Connection()
+	var handled interface{}
+	c.Handle("message", func(m map[string]interface{}) error {
+		handled = m["document"].(map[string]interface{})["file_size"]
+		return nil
+	})
+	h := &telegramtest.Harness{Connection: c}
+	if d := h.Inject(`{"update_id": 1, "message": {"message_id": 1, "chat": {"id": 1}, "document": {"file_id": "a", "file_size": ` + size + `}}}`); d.Err != nil {
+		t.Fatal(d.Err)
+	}
+	if n, ok := handled.(json.Number); !ok || n.String() != size {
+		t.Errorf("handler got file_size %#v, want %s", handled, size)
+	}
+	s.Respond("getFile", json.RawMessage(`{"file_id": "a", "file_size": `+size+`}`))
+	result := map[string]interface{}{}
+	if err := c.Call("getFile", map[string]interface{}{"file_id": "a"}, &result); err != nil {
+		t.Fatal(err)
+	}
+	if n, ok := result["file_size"].(json.Number); !ok || n.String() != size {
+		t.Errorf("result has file_size %#v, want %s", result["file_size"], size)
+	}
+	if err := c.Call("sendDocument", map[string]interface{}{"chat_id": 1, "document": "a", "size": result["file_size"]}, nil); err != nil {
+		t.Fatal(err)
+	}
+	if got := s.Calls("sendDocument")[0].Params["size"]; got != size {
+		t.Errorf("re-encoded file_size %s, want %s", got, size)
+	}
+}
+
+func TestValidateToken(t *testing.T) {
+	hash := strings.Repeat("a", 35)
+	for _, token := range []string{"", "123456", "123456" + hash, "abc:" + hash, "123456:" + hash[1:], "123456:" + hash + "a", "123456:" + hash[1:] + "!", " 123456:" + hash} {
+		if err := telegram.ValidateToken(token); err == nil {
+			t.Errorf("expected %q to be rejected", token)
+		}
+	}
+	for _, token := range []string{telegramtest.Token, "123456:" + hash, "1:" + strings.Repeat("A-_9", 8) + "abc"} {
+		if err := telegram.ValidateToken(token); err != nil {
+			t.Errorf("expected %q to be accepted: %s", token, err)
+		}
+	}
+
+	s := telegramtest.NewServer()
+	defer s.Close()
+	c := s.Connection()
+	c.Token, c.CheckToken = "123456", true
+	if err := c.Start(); err == nil || !strings.Contains(err.Error(), "malformed bot token") {
+		t.Fatalf("expected Start to fail fast, got %v", err)
+	} else if calls := s.Calls(""); len(calls) != 0 {
+		t.Fatalf("expected no calls, got %v", calls)
+	}
+}
+
+func TestBeforeEncode(t *testing.T) {
+	s := telegramtest.NewServer()
+	defer s.Close()
+	c := s.Connection()
+	seen := map[string]map[string]interface{}{}
+	c.BeforeEncode = func(method string, params map[string]interface{}) {
+		seen[method] = map[string]interface{}{}
+		for k, v := range params {
+			seen[method][k] = v
+		}
+		params["text"] = "changed"
+		delete(params, "disable_notification")
+	}
+	markup := telegram.InlineKeyboardMarkup{}
+	if _, err := c.SendMessage(1, "hi", telegram.WithReplyMarkup(markup), telegram.WithDisableNotification(true)); err != nil {
+		t.Fatal(err)
+	}
+	if params := seen["sendMessage"]; params["text"] != "hi" || !reflect.DeepEqual(params["reply_markup"], markup) || params["disable_notification"] != true {
+		t.Errorf("unexpected params %#v", params)
+	}
+	if params := s.Calls("sendMessage")[0].Params; params["text"] != "changed" || params["disable_notification"] != "" {
+		t.Errorf("expected the hook's changes to be sent, got %v", params)
+	}
+}
+
+func TestUnorderedUpdates(t *testing.T) {
+	s := telegramtest.NewServer()
+	defer s.Close()
+	for _, id := range []int{5, 7, 6} {
+		s.InjectUpdate(fmt.Sprintf(`{"update_id": %d, "message": {"message_id": %d, "chat": {"id": 1}, "text": "hi"}}`, id, id))
+	}
+	transport := &pollTransport{}
+	c := s.Connection()
+	c.Client = &http.Client{Transport: transport}
+	mu, handled := sync.Mutex{}, map[int]int{}
+	c.Handle("message", func(m telegram.Message) error {
+		mu.Lock()
+		defer mu.Unlock()
+		handled[m.ID]++
+		return nil
+	})
+	go c.Start()
+	defer c.StopAndWait()
+	for deadline := time.Now().Add(time.Second); ; time.Sleep(10 * time.Millisecond) {
+		transport.Lock()
+		polls := transport.polls
+		transport.Unlock()
+		if len(polls) >= 2 {
+			if offset := polls[1].Get("offset"); offset != "8" {
+				t.Errorf("expected the next offset to be 8, got %s", offset)
+			}
+			break
+		} else if time.Now().After(deadline) {
+			t.Fatal("timed out waiting for the second poll")
+		}
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	if want := map[int]int{5: 1, 6: 1, 7: 1}; !reflect.DeepEqual(handled, want) {
+		t.Errorf("expected each update to be handled once, got %v", handled)
+	}
+}
+
+func TestRequestHeaders(t *testing.T) {
+	mu, headers := sync.Mutex{}, map[string]http.Header{}
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		headers[r.URL.Path] = r.Header
+		mu.Unlock()
+		if strings.HasPrefix(r.URL.Path, "/file/") {
+			w.Write([]byte("data"))
+		} else {
+			w.Write([]byte(`{"ok": true, "result": {"file_id": "a", "file_path": "photos/a.jpg"}}`))
+		}
+	}))
+	defer s.Close()
+	c := &telegram.Connection{Token: telegramtest.Token, BaseURL: s.URL}
+	file, err := c.GetFile("a")
+	if err != nil {
+		t.Fatal(err)
+	}
+	c.UserAgent, c.ExtraHeaders = "my-bot/1.0", http.Header{"X-Team": {"bots"}}
+	r, err := c.DownloadFile(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	r.Close()
+	if _, err := c.GetFile("a"); err != nil {
+		t.Fatal(err)
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	api, download := headers["/bot"+telegramtest.Token+"/getFile"], headers["/file/bot"+telegramtest.Token+"/photos/a.jpg"]
+	if api.Get("User-Agent") != "my-bot/1.0" || api.Get("X-Team") != "bots" {
+		t.Errorf("unexpected api headers %v", api)
+	}
+	if download.Get("User-Agent") != "my-bot/1.0" || download.Get("X-Team") != "bots" {
+		t.Errorf("unexpected download headers %v", download)
+	}
+}
+
+func TestDefaultUserAgent(t *testing.T) {
+	agents := make(chan string, 1)
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		agents <- r.UserAgent()
+		w.Write([]byte(`{"ok": true, "result": true}`))
+	}))
+	defer s.Close()
+	c := &telegram.Connection{Token: telegramtest.Token, BaseURL: s.URL}
+	if err := c.Call("close", nil, nil); err != nil {
+		t.Fatal(err)
+	} else if agent := <-agents; !strings.HasPrefix(agent, "niklasfasching-telegram") {
+		t.Errorf("got User-Agent %q", agent)
+	}
+}
+
+func TestHandlerPanic(t *testing.T) {
+	for _, abort := range []bool{false, true} {
+		s := telegramtest.NewServer()
+		logger, handled := &testLogger{}, make(chan string, 2)
+		c := s.Connection()
+		c.Logger, c.AbortOnPanic = logger, abort
+		c.Handle("message", func(m telegram.Message) error {
+			if m.Text == "boom" {
+				var counts map[string]int
+				counts["boom"]++
+			}
+			handled <- m.Text
+			return nil
+		})
+		s.InjectUpdate(`{"message": {"message_id": 1, "chat": {"id": 1}, "text": "boom"}}`)
+		s.InjectUpdate(`{"message": {"message_id": 2, "chat": {"id": 1}, "text": "next"}}`)
+		errc := make(chan error, 1)
+		go func() { errc <- c.Start() }()
+		if !abort {
+			select {
+			case text := <-handled:
+				if text != "next" {
+					t.Errorf("expected the next update to be handled, got %q", text)
+				}
+			case err := <-errc:
+				t.Fatalf("Start returned %v", err)
+			case <-time.After(time.Second):
+				t.Fatal("timed out waiting for the next update")
+			}
+			if !logger.contains("assignment to entry in nil map") {
+				t.Errorf("expected the panic to be logged, got %v", logger.lines)
+			}
+			c.StopAndWait()
+		} else {
+			select {
+			case err := <-errc:
+				if e := (*telegram.PanicError)(nil); !errors.As(err, &e) || !strings.Contains(e.Update, "boom") || e.Stack == "" {
+					t.Errorf("expected a PanicError for the update, got %v", err)
+				}
+			case <-time.After(time.Second):
+				c.StopAndWait()
+				t.Fatal("expected Start to return on panic")
+			}
+			if len(handled) != 0 {
+				t.Errorf("expected no updates to be handled after the panic")
+			}
+		}
+		s.Close()
+	}
+}
+
+func TestPrivacyModeWarning(t *testing.T) {
+	tests := []struct {
+		name          string
+		canReadAll    bool
+		handleMessage bool
+		warn          bool
+	}{
+		{"privacy mode with message handler", false, true, true},
+		{"read all group messages", true, true, false},
+		{"privacy mode without message handler", false, false, false},
+	}
+	for _, test := range tests {
+		s := telegramtest.NewServer()
+		s.Bot.CanReadAllGroupMessages = test.canReadAll
+		logger, transport := &testLogger{}, &pollTransport{}
+		c := s.Connection()
+		c.Logger, c.Client = logger, &http.Client{Transport: transport}
+		if test.handleMessage {
+			c.Handle("message", func(telegram.Message) error { return nil })
+		} else {
+			c.Handle("callback_query", func(telegram.CallbackQuery) error { return nil })
+		}
+		go c.Start()
+		if transport.allowedUpdates(time.Second) == nil {
+			t.Fatalf("%s: timed out waiting for the first poll", test.name)
+		}
+		c.StopAndWait()
+		s.Close()
+		if warned := logger.contains("privacy mode is enabled"); warned != test.warn {
+			t.Errorf("%s: got warning %v, want %v: %v", test.name, warned, test.warn, logger.lines)
+		}
+	}
+}
+
+func TestStructParams(t *testing.T) {
+	s := telegramtest.NewServer()
+	defer s.Close()
+	type params struct {
+		ChatID           int64                          `json:"chat_id"`
+		Text             string                         `json:"text"`
+		ParseMode        string                         `json:"parse_mode,omitempty"`
+		ReplyMarkup      *telegram.InlineKeyboardMarkup `json:"reply_markup"`
+		Entities         []telegram.MessageEntity       `json:"entities,omitempty"`
+		LinkPreview      map[string]interface{}         `json:"link_preview_options"`
+		DisableNotify    bool                           `json:"disable_notification,omitempty"`
+		MessageThreadID  int                            `json:"message_thread_id,omitempty"`
+		BusinessID       string                         `json:",omitempty"`
+		ProtectContent   bool                           `json:"protect_content"`
+		internal, Ignore string                         `json:"-"`
+	}
+	c := s.Connection()
+	if err := c.Call("sendMessage", &params{ChatID: 1, Text: "hi", Entities: []telegram.MessageEntity{}}, nil); err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]string{"chat_id": "1", "text": "hi", "protect_content": "false"}
+	if got := s.Calls("sendMessage")[0].Params; !reflect.DeepEqual(got, want) {
+		t.Errorf("got params %v, want %v", got, want)
+	}
+	markup := telegram.NewInlineKeyboard().Row(telegram.Button("a", "a"))
+	if err := c.Call("sendMessage", params{ChatID: 1, Text: "hi", ReplyMarkup: markup, MessageThreadID: 5}, nil); err != nil {
+		t.Fatal(err)
+	}
+	if got := s.Calls("sendMessage")[1].Params; got["reply_markup"] != `{"inline_keyboard":[[{"text":"a","callback_data":"a"}]]}` || got["message_thread_id"] != "5" {
+		t.Errorf("unexpected params %v", got)
+	}
+}
+
+// urlTransport records the requested URLs and answers them without a network.
+type urlTransport struct{ urls []string }
+
+func (t *urlTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	t.urls = append(t.urls, req.URL.String())
+	body := `{"ok": true, "result": {"file_id": "id", "file_path": "photos/a.jpg"}}`
+	if strings.Contains(req.URL.Path, "/file/") {
+		body = "data"
+	}
+	return &http.Response{StatusCode: 200, Header: http.Header{}, Body: ioutil.NopCloser(strings.NewReader(body)), Request: req}, nil
+}
+
+func TestBaseURL(t *testing.T) {
+	for _, test := range []struct{ baseURL, want string }{
+		{"", "https://api.telegram.org"},
+		{"http://localhost:8081/", "http://localhost:8081"},
+	} {
+		transport := &urlTransport{}
+		c := &telegram.Connection{Token: "123:abc", BaseURL: test.baseURL, Client: &http.Client{Transport: transport}}
+		r, err := c.Download("id")
+		if err != nil {
+			t.Fatal(err)
+		}
+		r.Close()
+		want := []string{test.want + "/bot123:abc/getFile", test.want + "/file/bot123:abc/photos/a.jpg"}
+		if !reflect.DeepEqual(transport.urls, want) {
+			t.Errorf("BaseURL %q: got urls %v, want %v", test.baseURL, transport.urls, want)
+		}
+	}
+}
+
+func TestRenderRequest(t *testing.T) {
+	c := &telegram.Connection{ParseMode: telegram.ParseModeHTML, ProtectContent: true}
+	markup := telegram.InlineKeyboardMarkup{InlineKeyboard: [][]telegram.InlineKeyboardButton{{{Text: "a", CallbackData: "a"}}}}
+	params := map[string]interface{}{"chat_id": int64(1), "text": "<b>hi</b>"}
+	for _, o := range []telegram.Option{telegram.WithDisableNotification(true), telegram.WithReplyMarkup(markup)} {
+		o(params)
+	}
+	got, multipart, err := c.RenderRequest("sendMessage", params)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]interface{}{
+		"chat_id":              int64(1),
+		"text":                 "<b>hi</b>",
+		"parse_mode":           "HTML",
+		"protect_content":      true,
+		"disable_notification": true,
+		"reply_markup":         markup,
+	}
+	if multipart || !reflect.DeepEqual(got, want) {
+		t.Errorf("got %#v (multipart %v), want %#v", got, multipart, want)
+	}
+	if _, ok := params["parse_mode"]; ok {
+		t.Error("expected params not to be modified")
+	}
+}
@@ -0,0 +1,38 @@
+package telegramtest
+
+import (
+	"context"
+	"sync"
+
+	"github.com/niklasfasching/telegram"
+)
+
+type Dispatch struct {
+	Update string
+	Route  string
+	Err    error
+}
+
+// Harness injects updates into a Connection without polling and records which
+// route handled each of them, e.g. "command:start" or "message".
+type Harness struct {
+	Connection *telegram.Connection
+
+	mu         sync.Mutex
+	dispatches []Dispatch
+}
+
+func (h *Harness) Inject(update string) Dispatch {
+	route, err := h.Connection.Dispatch(context.Background(), []byte(update))
+	d := Dispatch{Update: update, Route: route, Err: err}
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.dispatches = append(h.dispatches, d)
+	return d
+}
+
+func (h *Harness) Dispatches() []Dispatch {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return append([]Dispatch(nil), h.dispatches...)
+}
@@ -0,0 +1,46 @@
+package telegramtest_test
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"github.com/niklasfasching/telegram"
+	"github.com/niklasfasching/telegram/telegramtest"
+)
+
+func TestHarnessRoutes(t *testing.T) {
+	c, handled := &telegram.Connection{}, []string{}
+	c.HandleCommand("start", func(ctx context.Context, m telegram.Message, args []string) error {
+		handled = append(handled, "start")
+		return nil
+	})
+	c.Handle("message", func(m telegram.Message) error {
+		handled = append(handled, "message:"+m.Text)
+		return nil
+	})
+	h := &telegramtest.Harness{Connection: c}
+	updates := []string{
+		`{"update_id": 1, "message": {"message_id": 1, "chat": {"id": 1}, "text": "/start"}}`,
+		`{"update_id": 2, "message": {"message_id": 2, "chat": {"id": 1}, "text": "hi"}}`,
+		`{"update_id": 3, "callback_query": {"id": "q", "from": {"id": 1}, "data": "x"}}`,
+	}
+	for _, u := range updates {
+		if d := h.Inject(u); d.Err != nil {
+			t.Fatal(d.Err)
+		}
+	}
+	routes := []string{}
+	for i, d := range h.Dispatches() {
+		if d.Update != updates[i] {
+			t.Errorf("dispatch %d: got update %s", i, d.Update)
+		}
+		routes = append(routes, d.Route)
+	}
+	if want := []string{"command:start", "message", "unhandled"}; !reflect.DeepEqual(routes, want) {
+		t.Errorf("got routes %q, want %q", routes, want)
+	}
+	if want := []string{"start", "message:hi"}; !reflect.DeepEqual(handled, want) {
+		t.Errorf("expected /start to only reach the command handler, got %v", handled)
+	}
+}
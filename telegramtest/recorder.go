@@ -0,0 +1,154 @@
+package telegramtest
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"mime"
+	"mime/multipart"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+)
+
+type Interaction struct {
+	Key        string `json:"key"`
+	StatusCode int    `json:"status_code"`
+	Body       string `json:"body"`
+}
+
+type RecordingTransport struct {
+	Path      string
+	Transport http.RoundTripper
+
+	mu           sync.Mutex
+	interactions []Interaction
+}
+
+type ReplayTransport struct {
+	mu           sync.Mutex
+	interactions []Interaction
+}
+
+func (t *RecordingTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	key, err := requestKey(req)
+	if err != nil {
+		return nil, err
+	}
+	transport := t.Transport
+	if transport == nil {
+		transport = http.DefaultTransport
+	}
+	res, err := transport.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+	defer res.Body.Close()
+	bs, err := ioutil.ReadAll(res.Body)
+	if err != nil {
+		return nil, err
+	}
+	res.Body = ioutil.NopCloser(bytes.NewReader(bs))
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.interactions = append(t.interactions, Interaction{key, res.StatusCode, string(bs)})
+	out, err := json.MarshalIndent(t.interactions, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return res, ioutil.WriteFile(t.Path, out, 0644)
+}
+
+func NewReplayTransport(path string) (*ReplayTransport, error) {
+	bs, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	t := &ReplayTransport{}
+	return t, json.Unmarshal(bs, &t.interactions)
+}
+
+func (t *ReplayTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	key, err := requestKey(req)
+	if err != nil {
+		return nil, err
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	for i, interaction := range t.interactions {
+		if interaction.Key == key {
+			t.interactions = append(t.interactions[:i], t.interactions[i+1:]...)
+			return &http.Response{
+				StatusCode: interaction.StatusCode,
+				Status:     fmt.Sprintf("%d %s", interaction.StatusCode, http.StatusText(interaction.StatusCode)),
+				Header:     http.Header{"Content-Type": {"application/json"}},
+				Body:       ioutil.NopCloser(strings.NewReader(interaction.Body)),
+				Request:    req,
+			}, nil
+		}
+	}
+	return nil, fmt.Errorf("no recorded interaction for %s", key)
+}
+
+func requestKey(req *http.Request) (string, error) {
+	parts := strings.Split(strings.TrimPrefix(req.URL.Path, "/"), "/")
+	for i, part := range parts {
+		if strings.HasPrefix(part, "bot") {
+			parts = append(parts[:i], parts[i+1:]...)
+			break
+		}
+	}
+	method := strings.Join(parts, "/")
+	if req.Body == nil {
+		return method, nil
+	}
+	bs, err := ioutil.ReadAll(req.Body)
+	if err != nil {
+		return "", err
+	}
+	req.Body.Close()
+	req.Body = ioutil.NopCloser(bytes.NewReader(bs))
+	params, err := normalizeBody(req.Header.Get("Content-Type"), bs)
+	if err != nil {
+		return "", err
+	}
+	if len(params) == 0 {
+		return method, nil
+	}
+	return method + "?" + params.Encode(), nil
+}
+
+func normalizeBody(contentType string, body []byte) (url.Values, error) {
+	params := url.Values{}
+	mediaType, mediaParams, err := mime.ParseMediaType(contentType)
+	if err != nil || mediaType != "multipart/form-data" {
+		if len(body) != 0 {
+			params.Set("body", string(body))
+		}
+		return params, nil
+	}
+	r := multipart.NewReader(bytes.NewReader(body), mediaParams["boundary"])
+	for {
+		part, err := r.NextPart()
+		if err == io.EOF {
+			return params, nil
+		} else if err != nil {
+			return nil, err
+		}
+		bs, err := ioutil.ReadAll(part)
+		if err != nil {
+			return nil, err
+		}
+		if part.FileName() != "" {
+			sum := sha256.Sum256(bs)
+			params.Add(part.FormName(), "sha256:"+hex.EncodeToString(sum[:]))
+		} else {
+			params.Add(part.FormName(), string(bs))
+		}
+	}
+}
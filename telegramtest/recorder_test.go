@@ -0,0 +1,39 @@
+package telegramtest_test
+
+import (
+	"net/http"
+	"path/filepath"
+	"testing"
+
+	"github.com/niklasfasching/telegram/telegramtest"
+)
+
+func TestRecordReplay(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "interactions.json")
+	s := telegramtest.NewServer()
+	c := s.Connection()
+	c.Client = &http.Client{Transport: &telegramtest.RecordingTransport{Path: path}}
+	recorded, err := c.SendMessage(1, "hi")
+	if err != nil {
+		t.Fatal(err)
+	}
+	s.Close()
+
+	replay, err := telegramtest.NewReplayTransport(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	c.Client, c.MaxRetries = &http.Client{Transport: replay}, -1
+	replayed, err := c.SendMessage(1, "hi")
+	if err != nil {
+		t.Fatal(err)
+	} else if replayed.ID != recorded.ID || replayed.Text != "hi" {
+		t.Errorf("replayed %#v, recorded %#v", replayed, recorded)
+	}
+	if _, err := c.SendMessage(1, "hi"); err == nil {
+		t.Error("expected each interaction to be replayed once")
+	}
+	if _, err := c.SendMessage(1, "other"); err == nil {
+		t.Error("expected an unrecorded request to fail")
+	}
+}
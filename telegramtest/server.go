@@ -0,0 +1,277 @@
+package telegramtest
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/niklasfasching/telegram"
+)
+
+// Token is accepted by Server and passes telegram.ValidateToken.
+const Token = "123456:AAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAA"
+
+// Call is a request the Server received. Params holds the form values as sent,
+// i.e. non-string params are JSON encoded. Files holds the uploads.
+type Call struct {
+	Method string
+	Params map[string]string
+	Files  map[string][]byte
+}
+
+type reply struct {
+	OK          bool                         `json:"ok"`
+	Result      interface{}                  `json:"result,omitempty"`
+	ErrorCode   int                          `json:"error_code,omitempty"`
+	Description string                       `json:"description,omitempty"`
+	Parameters  *telegram.ResponseParameters `json:"parameters,omitempty"`
+}
+
+// Server is an in-process fake Bot API server. It answers getUpdates with
+// injected updates, sendMessage with the sent message and everything else with
+// true unless told otherwise via Respond, RespondError and RespondFunc, which
+// apply to getUpdates as well. All calls but getUpdates are recorded.
+type Server struct {
+	*httptest.Server
+	Bot telegram.User
+
+	mu        sync.Mutex
+	calls     []Call
+	replies   map[string]reply
+	funcs     map[string]func(Call) interface{}
+	updates   []json.RawMessage
+	updateID  int
+	messageID int
+	signal    chan struct{}
+}
+
+func NewServer() *Server {
+	s := &Server{
+		Bot:     telegram.User{ID: 123456, FirstName: "Test", Username: "test_bot", IsBot: true},
+		replies: map[string]reply{},
+		funcs:   map[string]func(Call) interface{}{},
+		signal:  make(chan struct{}),
+	}
+	s.Server = httptest.NewServer(http.HandlerFunc(s.serveHTTP))
+	return s
+}
+
+// Connection returns a Connection talking to s.
+func (s *Server) Connection() *telegram.Connection {
+	return &telegram.Connection{Token: Token, BaseURL: s.URL, Timeout: time.Second}
+}
+
+func (s *Server) Respond(method string, result interface{}) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.replies[method] = reply{OK: true, Result: result}
+}
+
+func (s *Server) RespondError(method string, errorCode int, description string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.replies[method] = reply{ErrorCode: errorCode, Description: description}
+}
+
+// Error fails a call answered by RespondFunc. RetryAfter is sent along with
+// 429s.
+type Error struct {
+	Code        int
+	Description string
+	RetryAfter  int
+}
+
+// RespondFunc answers calls of method with the result of fn, e.g. to fake
+// state kept across calls. fn fails the call by returning an Error.
+func (s *Server) RespondFunc(method string, fn func(Call) interface{}) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.funcs[method] = fn
+}
+
+// Reset restores the default reply for method.
+func (s *Server) Reset(method string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.replies, method)
+	delete(s.funcs, method)
+}
+
+// InjectUpdate queues update for the next getUpdates. An update_id is added
+// unless update already has one.
+func (s *Server) InjectUpdate(update string) {
+	u := map[string]json.RawMessage{}
+	if err := json.Unmarshal([]byte(update), &u); err != nil {
+		panic(fmt.Errorf("invalid update %s: %w", update, err))
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if id, err := strconv.Atoi(string(u["update_id"])); err == nil {
+		s.updateID = id
+	} else {
+		s.updateID++
+		u["update_id"] = json.RawMessage(strconv.Itoa(s.updateID))
+	}
+	bs, _ := json.Marshal(u)
+	s.updates = append(s.updates, bs)
+	close(s.signal)
+	s.signal = make(chan struct{})
+}
+
+// Calls returns the calls of method received so far, or all calls for "".
+func (s *Server) Calls(method string) []Call {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	calls := []Call{}
+	for _, c := range s.calls {
+		if method == "" || c.Method == method {
+			calls = append(calls, c)
+		}
+	}
+	return calls
+}
+
+// SentMessages returns the texts sent via sendMessage so far.
+func (s *Server) SentMessages() []string {
+	texts := []string{}
+	for _, c := range s.Calls("sendMessage") {
+		texts = append(texts, c.Params["text"])
+	}
+	return texts
+}
+
+// WaitCall waits up to timeout for the nth (0 based) call of method, for calls
+// made by handlers running in a started Connection.
+func (s *Server) WaitCall(method string, n int, timeout time.Duration) (Call, error) {
+	for deadline := time.Now().Add(timeout); ; time.Sleep(10 * time.Millisecond) {
+		if calls := s.Calls(method); len(calls) > n {
+			return calls[n], nil
+		} else if time.Now().After(deadline) {
+			return Call{}, fmt.Errorf("timed out waiting for %s call %d, got %d", method, n, len(calls))
+		}
+	}
+}
+
+func (s *Server) serveHTTP(w http.ResponseWriter, r *http.Request) {
+	parts := strings.Split(strings.TrimPrefix(r.URL.Path, "/"), "/")
+	if len(parts) != 2 || parts[0] != "bot"+Token {
+		s.write(w, reply{ErrorCode: 401, Description: "Unauthorized"})
+		return
+	}
+	call, err := readCall(parts[1], r)
+	if err != nil {
+		s.write(w, reply{ErrorCode: 400, Description: "Bad Request: " + err.Error()})
+		return
+	}
+	s.mu.Lock()
+	rep, ok := s.replies[call.Method]
+	fn := s.funcs[call.Method]
+	if call.Method != "getUpdates" {
+		s.calls = append(s.calls, call)
+		if !ok && fn == nil {
+			rep = s.defaultReply(call)
+		}
+	}
+	s.mu.Unlock()
+	if fn != nil {
+		result := fn(call)
+		if e, ok := result.(Error); ok {
+			rep = reply{ErrorCode: e.Code, Description: e.Description}
+			if e.RetryAfter != 0 {
+				rep.Parameters = &telegram.ResponseParameters{RetryAfter: e.RetryAfter}
+			}
+		} else {
+			rep = reply{OK: true, Result: result}
+		}
+	} else if call.Method == "getUpdates" && !ok {
+		rep = reply{OK: true, Result: s.getUpdates(r, call)}
+	}
+	s.write(w, rep)
+}
+
+func (s *Server) getUpdates(r *http.Request, call Call) []json.RawMessage {
+	offset, _ := strconv.Atoi(call.Params["offset"])
+	timeout, _ := strconv.ParseFloat(call.Params["timeout"], 64)
+	deadline := time.After(time.Duration(timeout * float64(time.Second)))
+	for {
+		s.mu.Lock()
+		updates, signal := []json.RawMessage{}, s.signal
+		for _, u := range s.updates {
+			v := struct {
+				ID int `json:"update_id"`
+			}{}
+			if json.Unmarshal(u, &v) == nil && v.ID >= offset {
+				updates = append(updates, u)
+			}
+		}
+		s.mu.Unlock()
+		if len(updates) != 0 {
+			return updates
+		}
+		select {
+		case <-signal:
+		case <-deadline:
+			return updates
+		case <-r.Context().Done():
+			return updates
+		}
+	}
+}
+
+func (s *Server) defaultReply(call Call) reply {
+	switch call.Method {
+	case "getMe":
+		return reply{OK: true, Result: s.Bot}
+	case "sendMessage":
+		s.messageID++
+		m := map[string]interface{}{
+			"message_id": s.messageID,
+			"from":       s.Bot,
+			"date":       time.Now().Unix(),
+			"chat":       json.RawMessage(`{"id":` + call.Params["chat_id"] + `}`),
+			"text":       call.Params["text"],
+		}
+		return reply{OK: true, Result: m}
+	}
+	return reply{OK: true, Result: true}
+}
+
+func (s *Server) write(w http.ResponseWriter, r reply) {
+	w.Header().Set("Content-Type", "application/json")
+	if !r.OK {
+		w.WriteHeader(r.ErrorCode)
+	}
+	json.NewEncoder(w).Encode(r)
+}
+
+func readCall(method string, r *http.Request) (Call, error) {
+	call := Call{Method: method, Params: map[string]string{}, Files: map[string][]byte{}}
+	if !strings.HasPrefix(r.Header.Get("Content-Type"), "multipart/form-data") {
+		return call, nil
+	}
+	if err := r.ParseMultipartForm(32 << 20); err != nil {
+		return call, err
+	}
+	for k, vs := range r.MultipartForm.Value {
+		call.Params[k] = vs[0]
+	}
+	for k, fs := range r.MultipartForm.File {
+		f, err := fs[0].Open()
+		if err != nil {
+			return call, err
+		}
+		bs, err := ioutil.ReadAll(f)
+		f.Close()
+		if err != nil {
+			return call, err
+		}
+		call.Files[k] = bs
+	}
+	return call, nil
+}
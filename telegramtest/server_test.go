@@ -0,0 +1,71 @@
+package telegramtest_test
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/niklasfasching/telegram"
+	"github.com/niklasfasching/telegram/telegramtest"
+)
+
+func TestServer(t *testing.T) {
+	s := telegramtest.NewServer()
+	defer s.Close()
+	c := s.Connection()
+	c.Handle("message", func(m telegram.Message) error {
+		_, err := c.SendMessage(m.Chat.ID, "echo: "+m.Text)
+		return err
+	})
+	s.InjectUpdate(`{"message": {"message_id": 1, "chat": {"id": 1}, "text": "a"}}`)
+	go c.Start()
+	defer c.StopAndWait()
+	if call, err := s.WaitCall("sendMessage", 0, time.Second); err != nil {
+		t.Fatal(err)
+	} else if call.Params["chat_id"] != "1" || call.Params["text"] != "echo: a" {
+		t.Errorf("unexpected call %v", call)
+	}
+	s.InjectUpdate(`{"message": {"message_id": 2, "chat": {"id": 1}, "text": "b"}}`)
+	if _, err := s.WaitCall("sendMessage", 1, time.Second); err != nil {
+		t.Fatal(err)
+	}
+	if texts, want := s.SentMessages(), []string{"echo: a", "echo: b"}; !reflect.DeepEqual(texts, want) {
+		t.Errorf("got sent messages %q, want %q", texts, want)
+	}
+	methods := []string{}
+	for _, call := range s.Calls("") {
+		methods = append(methods, call.Method)
+	}
+	if want := []string{"getMe", "sendMessage", "sendMessage"}; !reflect.DeepEqual(methods, want) {
+		t.Errorf("expected all calls but getUpdates to be recorded, got %v", methods)
+	}
+}
+
+func TestServerResponses(t *testing.T) {
+	s := telegramtest.NewServer()
+	defer s.Close()
+	c := s.Connection()
+	c.MaxRetries = -1
+	if m, err := c.SendMessage(5, "hi"); err != nil || m.Chat.ID != 5 || m.Text != "hi" || m.From.Username != s.Bot.Username {
+		t.Errorf("unexpected default sendMessage reply %v %v", m, err)
+	}
+	s.Respond("getChat", telegram.Chat{ID: 5, Title: "chat"})
+	if chat, err := c.GetChat(5); err != nil || chat.Title != "chat" {
+		t.Errorf("unexpected getChat reply %v %v", chat, err)
+	}
+	s.RespondError("sendMessage", 403, "Forbidden: bot was blocked by the user")
+	if _, err := c.SendMessage(5, "hi"); err == nil {
+		t.Error("expected the error response")
+	} else if e := (*telegram.APIError)(nil); !errors.As(err, &e) || e.ErrorCode != 403 {
+		t.Errorf("expected a 403, got %v", err)
+	}
+	s.Reset("sendMessage")
+	if _, err := c.SendMessage(5, "hi"); err != nil {
+		t.Errorf("expected the default reply after Reset, got %v", err)
+	}
+	wrong := &telegram.Connection{Token: "1:wrong", BaseURL: c.BaseURL, MaxRetries: -1}
+	if _, err := wrong.SendMessage(5, "hi"); err == nil {
+		t.Error("expected an unknown token to be rejected")
+	}
+}
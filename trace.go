@@ -0,0 +1,32 @@
+package telegram
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+)
+
+type traceKey struct{}
+
+// TraceID returns the id of the update whose handling ctx belongs to. Command,
+// callback and topic handlers as well as handlers registered as
+// func(context.Context, T) error receive such a ctx; pass it on to CallContext
+// to have the resulting debug output tagged with the same id.
+func TraceID(ctx context.Context) string {
+	id, _ := ctx.Value(traceKey{}).(string)
+	return id
+}
+
+func withTraceID(ctx context.Context, update map[string]json.RawMessage) context.Context {
+	bs := make([]byte, 4)
+	rand.Read(bs)
+	return context.WithValue(ctx, traceKey{}, string(update["update_id"])+"-"+hex.EncodeToString(bs))
+}
+
+func tracePrefix(ctx context.Context, prefix string) string {
+	if id := TraceID(ctx); id != "" {
+		return "[" + id + "] " + prefix
+	}
+	return prefix
+}
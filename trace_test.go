@@ -0,0 +1,66 @@
+package telegram_test
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/niklasfasching/telegram"
+	"github.com/niklasfasching/telegram/telegramtest"
+)
+
+type testLogger struct {
+	sync.Mutex
+	lines []string
+}
+
+func (l *testLogger) Printf(format string, v ...interface{}) {
+	l.Lock()
+	defer l.Unlock()
+	l.lines = append(l.lines, fmt.Sprintf(format, v...))
+}
+
+func (l *testLogger) contains(s string) bool {
+	l.Lock()
+	defer l.Unlock()
+	for _, line := range l.lines {
+		if strings.Contains(line, s) {
+			return true
+		}
+	}
+	return false
+}
+
+func TestTraceID(t *testing.T) {
+	s := telegramtest.NewServer()
+	defer s.Close()
+	logger, ids := &testLogger{}, map[string]string{}
+	c := s.Connection()
+	c.Debug, c.Logger = true, logger
+	c.HandleCommand("start", func(ctx context.Context, m telegram.Message, args []string) error {
+		ids["command"] = telegram.TraceID(ctx)
+		return c.CallContext(ctx, "sendMessage", map[string]interface{}{"chat_id": m.Chat.ID, "text": "hi"}, nil)
+	})
+	c.HandleCallback("vote:", func(ctx context.Context, q telegram.CallbackQuery, payload string) error {
+		ids["callback"] = telegram.TraceID(ctx)
+		return c.CallContext(ctx, "sendMessage", map[string]interface{}{"chat_id": 1, "text": payload}, nil)
+	})
+	c.Handle("message", func(ctx context.Context, m telegram.Message) error {
+		ids["message"] = telegram.TraceID(ctx)
+		return c.CallContext(ctx, "sendMessage", map[string]interface{}{"chat_id": m.Chat.ID, "text": "echo"}, nil)
+	})
+	h := &telegramtest.Harness{Connection: c}
+	h.Inject(`{"update_id": 1, "message": {"message_id": 1, "chat": {"id": 1}, "text": "/start"}}`)
+	h.Inject(`{"update_id": 2, "callback_query": {"id": "q", "from": {"id": 1}, "data": "vote:yes"}}`)
+	h.Inject(`{"update_id": 3, "message": {"message_id": 2, "chat": {"id": 1}, "text": "hello"}}`)
+	for route, updateID := range map[string]string{"command": "1", "callback": "2", "message": "3"} {
+		id := ids[route]
+		if !strings.HasPrefix(id, updateID+"-") {
+			t.Errorf("%s: trace id %q does not belong to update %s", route, id, updateID)
+		} else if !logger.contains("[" + id + "] sendMessage") {
+			t.Errorf("%s: no sendMessage log line tagged with %s in %v", route, id, logger.lines)
+		}
+	}
+}
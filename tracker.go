@@ -0,0 +1,38 @@
+package telegram
+
+import "sync"
+
+type RequestTracker struct {
+	mu      sync.Mutex
+	next    int
+	pending map[int]interface{}
+}
+
+func (t *RequestTracker) Track(value interface{}) int {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.pending == nil {
+		t.pending = map[int]interface{}{}
+	}
+	t.next++
+	t.pending[t.next] = value
+	return t.next
+}
+
+func (t *RequestTracker) Resolve(requestID int) (interface{}, bool) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	value, ok := t.pending[requestID]
+	delete(t.pending, requestID)
+	return value, ok
+}
+
+func (t *RequestTracker) ChatButton(text string, request KeyboardButtonRequestChat, value interface{}) KeyboardButton {
+	request.RequestID = t.Track(value)
+	return KeyboardButton{Text: text, RequestChat: &request}
+}
+
+func (t *RequestTracker) UsersButton(text string, request KeyboardButtonRequestUsers, value interface{}) KeyboardButton {
+	request.RequestID = t.Track(value)
+	return KeyboardButton{Text: text, RequestUsers: &request}
+}
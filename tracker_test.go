@@ -0,0 +1,55 @@
+package telegram_test
+
+import (
+	"encoding/json"
+	"fmt"
+	"testing"
+
+	"github.com/niklasfasching/telegram"
+	"github.com/niklasfasching/telegram/telegramtest"
+)
+
+func TestRequestTrackerChatShared(t *testing.T) {
+	tracker := &telegram.RequestTracker{}
+	tracker.ChatButton("group", telegram.KeyboardButtonRequestChat{}, "group")
+	button := tracker.ChatButton("channel", telegram.KeyboardButtonRequestChat{ChatIsChannel: true}, "channel")
+	bs, err := json.Marshal(button)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sent := struct {
+		RequestChat struct {
+			RequestID int `json:"request_id"`
+		} `json:"request_chat"`
+	}{}
+	if err := json.Unmarshal(bs, &sent); err != nil || sent.RequestChat.RequestID == 0 {
+		t.Fatalf("expected a request_id in %s: %v", bs, err)
+	}
+
+	s := telegramtest.NewServer()
+	defer s.Close()
+	c := s.Connection()
+	resolved := []interface{}{}
+	c.Handle("message", func(m telegram.Message) error {
+		if m.ChatShared == nil {
+			return fmt.Errorf("expected chat_shared in %#v", m)
+		}
+		value, ok := tracker.Resolve(m.ChatShared.RequestID)
+		if !ok {
+			return fmt.Errorf("unknown request_id %d", m.ChatShared.RequestID)
+		}
+		resolved = append(resolved, value)
+		return nil
+	})
+	h := &telegramtest.Harness{Connection: c}
+	update := fmt.Sprintf(`{"update_id": 1, "message": {"message_id": 1, "chat": {"id": 1}, "chat_shared": {"request_id": %d, "chat_id": -100}}}`, sent.RequestChat.RequestID)
+	if d := h.Inject(update); d.Err != nil {
+		t.Fatal(d.Err)
+	}
+	if len(resolved) != 1 || resolved[0] != "channel" {
+		t.Errorf("expected the channel request to be resolved, got %v", resolved)
+	}
+	if d := h.Inject(update); d.Err == nil {
+		t.Error("expected a request to resolve only once")
+	}
+}
@@ -0,0 +1,86 @@
+package telegram
+
+import "encoding/json"
+
+func (u Update) EffectiveMessage() (*Message, bool) {
+	for _, m := range []*Message{u.Message, u.EditedMessage, u.ChannelPost, u.EditedChannelPost} {
+		if m != nil {
+			return m, true
+		}
+	}
+	if u.CallbackQuery != nil && u.CallbackQuery.Message != nil {
+		return u.CallbackQuery.Message, true
+	}
+	return nil, false
+}
+
+func (u Update) EffectiveChat() (Chat, bool) {
+	if m, ok := u.EffectiveMessage(); ok {
+		return m.Chat, true
+	} else if u.ChatBoost != nil {
+		return u.ChatBoost.Chat, true
+	} else if u.RemovedChatBoost != nil {
+		return u.RemovedChatBoost.Chat, true
+	} else if m := u.chatMemberUpdated(); m != nil {
+		return m.Chat, true
+	} else if u.MessageReaction != nil {
+		return u.MessageReaction.Chat, true
+	} else if u.MessageReactionCount != nil {
+		return u.MessageReactionCount.Chat, true
+	}
+	return Chat{}, false
+}
+
+func (u Update) EffectiveUser() (User, bool) {
+	switch {
+	case u.CallbackQuery != nil:
+		return u.CallbackQuery.From, true
+	case u.chatMemberUpdated() != nil:
+		return u.chatMemberUpdated().From, true
+	case u.InlineQuery != nil:
+		return u.InlineQuery.From, true
+	case u.ChosenInlineResult != nil:
+		return u.ChosenInlineResult.From, true
+	case u.PollAnswer != nil && u.PollAnswer.User != nil:
+		return *u.PollAnswer.User, true
+	case u.MessageReaction != nil && u.MessageReaction.User != nil:
+		return *u.MessageReaction.User, true
+	case u.ShippingQuery != nil:
+		return u.ShippingQuery.From, true
+	case u.PreCheckoutQuery != nil:
+		return u.PreCheckoutQuery.From, true
+	case u.ChatBoost != nil && u.ChatBoost.Boost.Source.User != nil:
+		return *u.ChatBoost.Boost.Source.User, true
+	case u.RemovedChatBoost != nil && u.RemovedChatBoost.Source.User != nil:
+		return *u.RemovedChatBoost.Source.User, true
+	}
+	if m, ok := u.EffectiveMessage(); ok && m.From.ID != 0 {
+		return m.From, true
+	}
+	return User{}, false
+}
+
+func (u Update) chatMemberUpdated() *ChatMemberUpdated {
+	if u.MyChatMember != nil {
+		return u.MyChatMember
+	}
+	return u.ChatMember
+}
+
+func updateChatID(update map[string]json.RawMessage) (int64, bool) {
+	u, err := decodeUpdate(update)
+	if err != nil {
+		return 0, false
+	}
+	chat, ok := u.EffectiveChat()
+	return chat.ID, ok
+}
+
+func decodeUpdate(update map[string]json.RawMessage) (Update, error) {
+	u := Update{}
+	bs, err := json.Marshal(update)
+	if err != nil {
+		return u, err
+	}
+	return u, unmarshalJSON(bs, &u)
+}
@@ -0,0 +1,38 @@
+package telegram_test
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/niklasfasching/telegram"
+)
+
+func TestEffective(t *testing.T) {
+	tests := []struct {
+		name           string
+		update         string
+		chatID, userID int64
+		hasMessage     bool
+	}{
+		{"message", `{"message": {"message_id": 1, "from": {"id": 2}, "chat": {"id": 1}, "text": "hi"}}`, 1, 2, true},
+		{"channel post", `{"channel_post": {"message_id": 1, "chat": {"id": -100}, "text": "hi"}}`, -100, 0, true},
+		{"callback query", `{"callback_query": {"id": "q", "from": {"id": 3}, "message": {"message_id": 1, "from": {"id": 9}, "chat": {"id": 1}}}}`, 1, 3, true},
+		{"inline callback query", `{"callback_query": {"id": "q", "from": {"id": 3}, "inline_message_id": "i"}}`, 0, 3, false},
+		{"inline query", `{"inline_query": {"id": "q", "from": {"id": 4}, "query": "cats"}}`, 0, 4, false},
+	}
+	for _, test := range tests {
+		u := telegram.Update{}
+		if err := json.Unmarshal([]byte(test.update), &u); err != nil {
+			t.Fatal(err)
+		}
+		if chat, ok := u.EffectiveChat(); chat.ID != test.chatID || ok != (test.chatID != 0) {
+			t.Errorf("%s: EffectiveChat() = %d, %v", test.name, chat.ID, ok)
+		}
+		if user, ok := u.EffectiveUser(); user.ID != test.userID || ok != (test.userID != 0) {
+			t.Errorf("%s: EffectiveUser() = %d, %v", test.name, user.ID, ok)
+		}
+		if m, ok := u.EffectiveMessage(); ok != test.hasMessage || ok && m.Chat.ID != test.chatID {
+			t.Errorf("%s: EffectiveMessage() = %#v, %v", test.name, m, ok)
+		}
+	}
+}
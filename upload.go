@@ -0,0 +1,118 @@
+package telegram
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"mime/multipart"
+)
+
+var errNotRewindable = errors.New("upload can't be retried, its reader is not an io.Seeker")
+
+// requestBody encodes the params of a call. Params without uploads are encoded
+// once and reused for retries. Uploads are streamed through a pipe instead so
+// memory use doesn't grow with the file size; retrying them seeks their readers
+// back to where they started.
+type requestBody struct {
+	params      map[string]interface{}
+	streamed    bool
+	buffered    []byte
+	contentType string
+	offsets     map[string]int64
+	pipe        *io.PipeReader
+	done        chan struct{}
+}
+
+type progressReader struct {
+	*io.PipeReader
+	sent     int64
+	progress func(sent int64)
+}
+
+func newRequestBody(params map[string]interface{}) (*requestBody, error) {
+	b := &requestBody{params: params, streamed: hasReader(params), offsets: map[string]int64{}}
+	if !b.streamed {
+		body, contentType, err := encodeMultipartBody(params)
+		if err != nil {
+			return nil, err
+		}
+		b.buffered, b.contentType = body.Bytes(), contentType
+		return b, nil
+	}
+	for k, v := range params {
+		if s, ok := v.(io.Seeker); ok {
+			offset, err := s.Seek(0, io.SeekCurrent)
+			if err != nil {
+				return nil, err
+			}
+			b.offsets[k] = offset
+		}
+	}
+	return b, nil
+}
+
+// refresh re-encodes buffered params after they were changed; streamed params
+// are encoded anew whenever the body is opened anyway.
+func (b *requestBody) refresh() error {
+	if b.streamed {
+		return nil
+	}
+	body, contentType, err := encodeMultipartBody(b.params)
+	if err != nil {
+		return err
+	}
+	b.buffered, b.contentType = body.Bytes(), contentType
+	return nil
+}
+
+func (b *requestBody) rewindable() bool {
+	for k, v := range b.params {
+		if _, ok := v.(io.Reader); ok {
+			if _, ok := b.offsets[k]; !ok {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+func (b *requestBody) open(progress func(sent int64)) (io.Reader, string, error) {
+	if !b.streamed {
+		return bytes.NewReader(b.buffered), b.contentType, nil
+	}
+	if b.pipe != nil {
+		b.close()
+		if !b.rewindable() {
+			return nil, "", errNotRewindable
+		}
+		for k, offset := range b.offsets {
+			if _, err := b.params[k].(io.Seeker).Seek(offset, io.SeekStart); err != nil {
+				return nil, "", err
+			}
+		}
+	}
+	pr, pw := io.Pipe()
+	form := multipart.NewWriter(pw)
+	b.pipe, b.done = pr, make(chan struct{})
+	go func() {
+		defer close(b.done)
+		pw.CloseWithError(writeMultipartBody(form, b.params))
+	}()
+	return &progressReader{PipeReader: pr, progress: progress}, form.FormDataContentType(), nil
+}
+
+// close stops the upload goroutine and waits for it to stop reading the params.
+func (b *requestBody) close() {
+	if b.pipe != nil {
+		b.pipe.Close()
+		<-b.done
+	}
+}
+
+func (r *progressReader) Read(p []byte) (int, error) {
+	n, err := r.PipeReader.Read(p)
+	if r.sent += int64(n); n > 0 && r.progress != nil {
+		r.progress(r.sent)
+	}
+	return n, err
+}
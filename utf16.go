@@ -0,0 +1,43 @@
+package telegram
+
+import "unicode/utf16"
+
+func UTF16Len(s string) int {
+	n := 0
+	for _, r := range s {
+		n += runeLenUTF16(r)
+	}
+	return n
+}
+
+func UTF16Slice(s string, start, end int) string {
+	units := utf16.Encode([]rune(s))
+	if start < 0 {
+		start = 0
+	}
+	if end > len(units) {
+		end = len(units)
+	}
+	if start >= end {
+		return ""
+	}
+	return string(utf16.Decode(units[start:end]))
+}
+
+func RuneOffsetToUTF16(s string, runeOffset int) int {
+	n, i := 0, 0
+	for _, r := range s {
+		if i == runeOffset {
+			break
+		}
+		n, i = n+runeLenUTF16(r), i+1
+	}
+	return n
+}
+
+func runeLenUTF16(r rune) int {
+	if r >= 0x10000 {
+		return 2
+	}
+	return 1
+}
@@ -0,0 +1,62 @@
+package telegram_test
+
+import (
+	"testing"
+
+	"github.com/niklasfasching/telegram"
+)
+
+func TestUTF16(t *testing.T) {
+	const combining = "é" // e + combining acute accent, two runes in the BMP
+	tests := []struct {
+		s   string
+		len int
+	}{
+		{"", 0},
+		{"hello", 5},
+		{"héllo", 5},
+		{combining, 2},
+		{"👍", 2},
+		{"a👍b", 4},
+		{"👨‍👩‍👧", 8},
+	}
+	for _, test := range tests {
+		if n := telegram.UTF16Len(test.s); n != test.len {
+			t.Errorf("UTF16Len(%q) = %d, want %d", test.s, n, test.len)
+		}
+	}
+
+	slices := []struct {
+		s          string
+		start, end int
+		want       string
+	}{
+		{"hello", 1, 3, "el"},
+		{"a👍b", 1, 3, "👍"},
+		{"a👍b", 3, 4, "b"},
+		{"a👍b", -1, 10, "a👍b"},
+		{"a👍b", 3, 1, ""},
+		{"x" + combining + "y", 1, 3, combining},
+	}
+	for _, test := range slices {
+		if got := telegram.UTF16Slice(test.s, test.start, test.end); got != test.want {
+			t.Errorf("UTF16Slice(%q, %d, %d) = %q, want %q", test.s, test.start, test.end, got, test.want)
+		}
+	}
+
+	offsets := []struct {
+		s                  string
+		runeOffset, offset int
+	}{
+		{"hello", 3, 3},
+		{"a👍b", 2, 3},
+		{"👍👍", 1, 2},
+		{combining + "x", 2, 2},
+		{"ab", 5, 2},
+	}
+	for _, test := range offsets {
+		if got := telegram.RuneOffsetToUTF16(test.s, test.runeOffset); got != test.offset {
+			t.Errorf("RuneOffsetToUTF16(%q, %d) = %d, want %d", test.s, test.runeOffset, got, test.offset)
+		}
+	}
+}
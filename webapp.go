@@ -0,0 +1,97 @@
+package telegram
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"strconv"
+	"time"
+)
+
+type WebAppInfo struct {
+	URL string `json:"url"`
+}
+
+// WebAppData is sent by a Web App opened from a reply keyboard button via
+// Telegram.WebApp.sendData.
+type WebAppData struct {
+	Data       string `json:"data"`
+	ButtonText string `json:"button_text"`
+}
+
+// WebAppInitData is the parsed Telegram.WebApp.initData of a Web App. QueryID is
+// only set for Web Apps opened from an inline keyboard button or the menu, see
+// AnswerWebAppQuery.
+type WebAppInitData struct {
+	QueryID      string
+	User         *User
+	Receiver     *User
+	Chat         *Chat
+	ChatType     string
+	ChatInstance string
+	StartParam   string
+	AuthDate     time.Time
+	Values       url.Values
+}
+
+type SentWebAppMessage struct {
+	InlineMessageID string `json:"inline_message_id"`
+}
+
+func WebAppButton(text, url string) InlineKeyboardButton {
+	return InlineKeyboardButton{Text: text, WebApp: &WebAppInfo{URL: url}}
+}
+
+// ValidateWebAppInitData checks the hash of initData, the raw query string a Web
+// App receives as Telegram.WebApp.initData, and rejects data older than maxAge.
+// A maxAge of 0 disables the age check.
+func ValidateWebAppInitData(initData, token string, maxAge time.Duration) (WebAppInitData, error) {
+	d := WebAppInitData{}
+	values, err := url.ParseQuery(initData)
+	if err != nil {
+		return d, fmt.Errorf("invalid web app init data: %w", err)
+	}
+	data := map[string]string{}
+	for k := range values {
+		data[k] = values.Get(k)
+	}
+	secret := hmac.New(sha256.New, []byte("WebAppData"))
+	secret.Write([]byte(token))
+	mac := hmac.New(sha256.New, secret.Sum(nil))
+	mac.Write([]byte(dataCheckString(data)))
+	if hash, err := hex.DecodeString(data["hash"]); err != nil || !hmac.Equal(hash, mac.Sum(nil)) {
+		return d, fmt.Errorf("invalid web app init data hash")
+	}
+	authDate, err := strconv.ParseInt(data["auth_date"], 10, 64)
+	if err != nil {
+		return d, fmt.Errorf("invalid web app init data auth_date: %w", err)
+	}
+	if age := time.Since(time.Unix(authDate, 0)); maxAge > 0 && age > maxAge {
+		return d, fmt.Errorf("web app init data is outdated (%s old)", age.Round(time.Second))
+	}
+	d.QueryID, d.ChatType, d.ChatInstance, d.StartParam = data["query_id"], data["chat_type"], data["chat_instance"], data["start_param"]
+	d.AuthDate, d.Values = time.Unix(authDate, 0).UTC(), values
+	for k, v := range map[string]interface{}{"user": &d.User, "receiver": &d.Receiver, "chat": &d.Chat} {
+		if data[k] == "" {
+			continue
+		} else if err := json.Unmarshal([]byte(data[k]), v); err != nil {
+			return d, fmt.Errorf("invalid web app init data %s: %w", k, err)
+		}
+	}
+	return d, nil
+}
+
+// AnswerWebAppQuery sends result on behalf of the user that opened the Web App
+// with the given query id.
+func (c *Connection) AnswerWebAppQuery(webAppQueryID string, result InlineQueryResult) (SentWebAppMessage, error) {
+	m := SentWebAppMessage{}
+	r, err := marshalWithField(result, "type", result.inlineQueryResultType())
+	if err != nil {
+		return m, err
+	}
+	err = c.Call("answerWebAppQuery", map[string]interface{}{"web_app_query_id": webAppQueryID, "result": r}, &m)
+	return m, err
+}
@@ -0,0 +1,74 @@
+package telegram
+
+import (
+	"context"
+	"crypto/subtle"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"time"
+)
+
+const secretTokenHeader = "X-Telegram-Bot-Api-Secret-Token"
+
+// StartWebhook receives updates via a webhook instead of getUpdates. It
+// registers WebhookURL with Telegram and serves the webhook on addr and path
+// using plain http, i.e. TLS is expected to be terminated by a proxy in front.
+// Like Start, it blocks until Stop is called.
+func (c *Connection) StartWebhook(addr, path string) error {
+	return c.run(context.Background(), func(ctx context.Context) error {
+		params := map[string]interface{}{"url": c.WebhookURL, "allowed_updates": c.allowedUpdates()}
+		if c.WebhookSecret != "" {
+			params["secret_token"] = c.WebhookSecret
+		}
+		if err := c.CallContext(ctx, "setWebhook", params, nil); err != nil {
+			return err
+		}
+		c.setWebhookMode(true)
+		defer c.setWebhookMode(false)
+		mux := http.NewServeMux()
+		mux.Handle(path, c.WebhookHandler())
+		server, errc := &http.Server{Addr: addr, Handler: mux}, make(chan error, 1)
+		go func() { errc <- server.ListenAndServe() }()
+		select {
+		case err := <-errc:
+			return err
+		case <-ctx.Done():
+			shutdownCtx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+			defer cancel()
+			return server.Shutdown(shutdownCtx)
+		}
+	})
+}
+
+// WebhookHandler dispatches updates posted by Telegram through the registered
+// handlers. Handler errors go to OnError or the log - the request is still
+// acknowledged, as Telegram would otherwise deliver the update again and again.
+func (c *Connection) WebhookHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		if secret := r.Header.Get(secretTokenHeader); subtle.ConstantTimeCompare([]byte(secret), []byte(c.WebhookSecret)) != 1 {
+			http.Error(w, "invalid secret token", http.StatusUnauthorized)
+			return
+		}
+		bs, err := ioutil.ReadAll(r.Body)
+		if err != nil || !json.Valid(bs) {
+			http.Error(w, "invalid update", http.StatusBadRequest)
+			return
+		}
+		if _, err := c.Dispatch(r.Context(), bs); err != nil && c.OnError != nil {
+			c.OnError(err)
+		} else if err != nil {
+			c.logf("%s", err)
+		}
+	})
+}
+
+func (c *Connection) setWebhookMode(webhook bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.webhook = webhook
+}